@@ -1,22 +1,47 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-jar/golog"
 	"github.com/goinbox/gomisc"
 )
 
 type Client struct {
+	// logCnt is first to be 64-bit aligned for atomic operations
+	logCnt uint64
+
 	config *Config
 
 	db *sql.DB
 	tx *sql.Tx
 
+	txDepth int
+
+	// savedLockWaitTimeout holds the session innodb_lock_wait_timeout to restore when the transaction ends, 0 if untouched.
+	savedLockWaitTimeout int64
+
 	isConnClosed bool
+	// onFree is set by the Pool creating the client to stop tracking it
+	onFree func()
+
+	stmtCache   *stmtCache
+	retryPolicy *RetryPolicy
+
+	middlewares []Middleware
+	handler     Handler
+
+	// txSpan is the span of the current transaction, started by TracingMiddleware
+	txSpan Span
 
 	logger    golog.ILogger
 	traceId   []byte
@@ -37,14 +62,24 @@ func NewClient(config *Config, logger golog.ILogger) (*Client, error) {
 		logger = new(golog.NoopLogger)
 	}
 
-	return &Client{
+	client := &Client{
 		config:    config,
 		db:        db,
 		tx:        nil,
 		logger:    logger,
 		traceId:   []byte("-"),
 		logPrefix: nil,
-	}, nil
+	}
+
+	if config.StmtCacheSize > 0 {
+		client.EnableStmtCache(config.StmtCacheSize)
+	}
+	client.retryPolicy = config.RetryPolicy
+	if len(config.Middlewares) > 0 {
+		client.Use(config.Middlewares...)
+	}
+
+	return client, nil
 }
 
 func (c *Client) SetLogger(logger golog.ILogger) *Client {
@@ -70,108 +105,568 @@ func (c *Client) SetLogPrefix(logPrefix []byte) *Client {
 	return c
 }
 
+// EnableStmtCache makes the client prepare the statements having args on the server and keep up to size of them,
+// keyed by query text, the least recently used statement is closed when the cache is full. A size <= 0 disables the cache.
+func (c *Client) EnableStmtCache(size int) *Client {
+	if c.stmtCache != nil {
+		c.stmtCache.close()
+		c.stmtCache = nil
+	}
+
+	if size > 0 {
+		c.stmtCache = newStmtCache(size)
+	}
+	return c
+}
+
+func (c *Client) StmtCacheStats() StmtCacheStats {
+	if c.stmtCache == nil {
+		return StmtCacheStats{}
+	}
+
+	return c.stmtCache.stats()
+}
+
+// SetRetryPolicy sets the policy retrying statements which fail with a transient error, nil disables retries.
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) *Client {
+	c.retryPolicy = policy
+	return c
+}
+
 func (c *Client) IsClosed() bool {
 	return c.isConnClosed
 }
 
 func (c *Client) Free() {
+	if c.stmtCache != nil {
+		c.stmtCache.close()
+	}
 	c.db.Close()
 	c.tx = nil
+	c.txDepth = 0
+	c.savedLockWaitTimeout = 0
 	c.isConnClosed = true
+
+	if c.onFree != nil {
+		c.onFree()
+	}
 }
 
 func (c *Client) Exec(query string, args ...interface{}) (sql.Result, error) {
-	c.log(query, args...)
+	return c.ExecContext(context.Background(), query, args...)
+}
+
+func (c *Client) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	op := &Operation{Kind: OpExec, Query: query, Args: args}
+	err := c.handle(ctx, op)
+
+	return op.Result, err
+}
+
+func (c *Client) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), query, args...)
+}
+
+func (c *Client) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	op := &Operation{Kind: OpQuery, Query: query, Args: args}
+	err := c.handle(ctx, op)
+
+	return op.Rows, err
+}
+
+func (c *Client) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryRowContext returns the row of the query, when a middleware stops the query
+// the row reports context.Canceled since a *sql.Row can not carry another error.
+func (c *Client) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	op := &Operation{Kind: OpQueryRow, Query: query, Args: args}
+	_ = c.handle(ctx, op)
+
+	if op.Row == nil {
+		canceledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		return c.queryRow(canceledCtx, op.Query, op.Args...)
+	}
+
+	return op.Row
+}
+
+// Use appends middlewares to the chain wrapping every statement of the client, the first one being the outermost.
+// A pooled client keeps its middlewares, install them with Config.Middlewares to have them on every client of a pool.
+func (c *Client) Use(middlewares ...Middleware) *Client {
+	c.middlewares = append(c.middlewares, middlewares...)
+	c.handler = chainMiddlewares(c.run, c.middlewares)
+
+	return c
+}
+
+func (c *Client) handle(ctx context.Context, op *Operation) error {
+	op.Client = c
+
+	if c.handler == nil {
+		return c.run(ctx, op)
+	}
+
+	return c.handler(ctx, op)
+}
+
+// run is the innermost handler, it executes op.
+func (c *Client) run(ctx context.Context, op *Operation) error {
+	op.Start = time.Now()
+
+	switch op.Kind {
+	case OpExec:
+		op.Err = c.retry(ctx, c.retryPolicy != nil && c.retryPolicy.RetryWrites, func() error {
+			var err error
+			op.Result, err = c.exec(ctx, op.Query, op.Args...)
+			return err
+		})
+	case OpQuery:
+		op.Err = c.retry(ctx, true, func() error {
+			var err error
+			op.Rows, err = c.query(ctx, op.Query, op.Args...)
+			return err
+		})
+	case OpQueryRow:
+		op.Err = c.retry(ctx, true, func() error {
+			op.Row = c.queryRow(ctx, op.Query, op.Args...)
+			return op.Row.Err()
+		})
+	case OpBegin:
+		if c.tx != nil {
+			_, op.Err = c.exec(ctx, op.Query)
+		} else {
+			var tx *sql.Tx
+			tx, op.Err = c.db.BeginTx(ctx, op.txOptions)
+			if op.Err == nil {
+				c.tx = tx
+			}
+		}
+	case OpCommit:
+		if c.txDepth > 0 {
+			_, op.Err = c.exec(ctx, op.Query)
+		} else {
+			op.Err = c.tx.Commit()
+		}
+	case OpRollback:
+		if c.txDepth > 0 {
+			_, op.Err = c.exec(ctx, op.Query)
+		} else {
+			op.Err = c.tx.Rollback()
+		}
+	default:
+		op.Err = errors.New("Unknown operation " + op.Kind)
+	}
+
+	op.Duration = time.Since(op.Start)
+	op.Err = ClassifyError(op.Err)
+
+	c.log(ctx, op)
+
+	return op.Err
+}
+
+func (c *Client) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if c.useStmtCache(args) {
+		var result sql.Result
+		err := c.withStmt(ctx, query, func(stmt *sql.Stmt) error {
+			var err error
+			result, err = stmt.ExecContext(ctx, args...)
+			return err
+		})
+		return result, err
+	}
 
 	if c.tx != nil {
-		return c.tx.Exec(query, args...)
+		return c.tx.ExecContext(ctx, query, args...)
 	} else {
-		return c.db.Exec(query, args...)
+		return c.db.ExecContext(ctx, query, args...)
 	}
 }
 
-func (c *Client) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	c.log(query, args...)
+func (c *Client) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if c.useStmtCache(args) {
+		var rows *sql.Rows
+		err := c.withStmt(ctx, query, func(stmt *sql.Stmt) error {
+			var err error
+			rows, err = stmt.QueryContext(ctx, args...)
+			return err
+		})
+		return rows, err
+	}
 
 	if c.tx != nil {
-		return c.tx.Query(query, args...)
+		return c.tx.QueryContext(ctx, query, args...)
 	} else {
-		return c.db.Query(query, args...)
+		return c.db.QueryContext(ctx, query, args...)
 	}
 }
 
-func (c *Client) QueryRow(query string, args ...interface{}) *sql.Row {
-	c.log(query, args...)
+func (c *Client) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if c.useStmtCache(args) {
+		var row *sql.Row
+		_ = c.withStmt(ctx, query, func(stmt *sql.Stmt) error {
+			row = stmt.QueryRowContext(ctx, args...)
+			return row.Err()
+		})
+		if row != nil {
+			return row
+		}
+		// preparing failed, the plain query below reports the error through the row
+	}
 
 	if c.tx != nil {
-		return c.tx.QueryRow(query, args...)
+		return c.tx.QueryRowContext(ctx, query, args...)
 	} else {
-		return c.db.QueryRow(query, args...)
+		return c.db.QueryRowContext(ctx, query, args...)
 	}
 }
 
 func (c *Client) Begin() error {
-	tx, err := c.db.Begin()
+	return c.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a transaction bound to ctx, the driver rolls it back when ctx is done before Commit.
+// When a transaction is already open it issues SAVEPOINT sp_N instead, so transactions can be nested:
+// the matching Commit releases the savepoint, Rollback rolls back to it, and only the outermost Commit commits.
+func (c *Client) BeginTx(ctx context.Context, opts *sql.TxOptions) error {
+	if opts == nil {
+		return c.BeginWithOptionsCtx(ctx, nil)
+	}
+
+	return c.BeginWithOptionsCtx(ctx, &TxOptions{
+		Isolation: opts.Isolation,
+		ReadOnly:  opts.ReadOnly,
+	})
+}
+
+func (c *Client) BeginWithOptions(opts *TxOptions) error {
+	return c.BeginWithOptionsCtx(context.Background(), opts)
+}
+
+// BeginWithOptionsCtx is BeginTx with isolation level, read-only and lock wait timeout options,
+// options can not be applied to a nested transaction since a savepoint shares the outer transaction.
+func (c *Client) BeginWithOptionsCtx(ctx context.Context, opts *TxOptions) error {
+	if c.tx != nil {
+		if !opts.IsZero() {
+			return errors.New("Transaction options can not be applied to a nested transaction")
+		}
+
+		c.txDepth++
+
+		err := c.handle(ctx, &Operation{Kind: OpBegin, Query: "SAVEPOINT " + c.savepointName()})
+		if err != nil {
+			c.txDepth--
+			return err
+		}
+
+		return nil
+	}
+
+	op := &Operation{Kind: OpBegin, Query: "BEGIN"}
+	if !opts.IsZero() {
+		err := opts.validate()
+		if err != nil {
+			return err
+		}
+
+		op.Query += " " + opts.String()
+		op.txOptions = opts.txOptions()
+	}
+
+	err := c.handle(ctx, op)
 	if err != nil {
 		return err
 	}
 
-	c.log("BEGIN")
-	c.tx = tx
+	if !opts.IsZero() && opts.LockWaitTimeout > 0 {
+		err = c.setLockWaitTimeout(ctx, opts.lockWaitTimeoutSeconds())
+		if err != nil {
+			_ = c.Rollback()
+			return err
+		}
+	}
 
 	return nil
 }
 
 func (c *Client) Commit() error {
+	if c.tx == nil {
+		return errors.New("Not in transaction")
+	}
+
+	if c.txDepth > 0 {
+		defer func() {
+			c.txDepth--
+		}()
+
+		return c.handle(context.Background(), &Operation{Kind: OpCommit, Query: "RELEASE SAVEPOINT " + c.savepointName()})
+	}
+
 	defer func() {
 		c.tx = nil
 	}()
 
-	if c.tx != nil {
-		c.log("COMMIT")
+	c.restoreLockWaitTimeout()
 
-		return c.tx.Commit()
+	op := &Operation{Kind: OpCommit, Query: "COMMIT"}
+	err := c.handle(context.Background(), op)
+	if op.Start.IsZero() {
+		// a middleware stopped the commit, the transaction still has to release its connection
+		_ = c.tx.Rollback()
+		if err == nil {
+			err = errors.New("Commit stopped by a middleware, the transaction is rolled back")
+		}
 	}
 
-	return errors.New("Not in transaction")
+	return err
 }
 
 func (c *Client) Rollback() error {
+	if c.tx == nil {
+		return errors.New("Not in transaction")
+	}
+
+	if c.txDepth > 0 {
+		defer func() {
+			c.txDepth--
+		}()
+
+		return c.handle(context.Background(), &Operation{Kind: OpRollback, Query: "ROLLBACK TO SAVEPOINT " + c.savepointName()})
+	}
+
 	defer func() {
 		c.tx = nil
 	}()
 
-	if c.tx != nil {
-		c.log("ROLLBACK")
+	c.restoreLockWaitTimeout()
+
+	op := &Operation{Kind: OpRollback, Query: "ROLLBACK"}
+	err := c.handle(context.Background(), op)
+	if op.Start.IsZero() {
+		_ = c.tx.Rollback()
+	}
+
+	return err
+}
+
+func (c *Client) InTransaction() bool {
+	return c.tx != nil
+}
+
+// TransactionDepth returns 0 outside a transaction, 1 in the outermost one and one more for each nested level.
+func (c *Client) TransactionDepth() int {
+	if c.tx == nil {
+		return 0
+	}
+
+	return c.txDepth + 1
+}
+
+// WithTransaction runs fn in a transaction, it commits when fn returns nil and
+// rolls back when fn returns an error or panics, the panic is re-raised after the rollback.
+func (c *Client) WithTransaction(fn func(tx *Client) error) error {
+	return c.WithTransactionCtx(context.Background(), fn)
+}
+
+func (c *Client) WithTransactionCtx(ctx context.Context, fn func(tx *Client) error) error {
+	return c.WithTransactionOptionsCtx(ctx, nil, fn)
+}
+
+// WithTransactionOptionsCtx is WithTransactionCtx with transaction options, when the retry policy has
+// ReplayTransactions the outermost transaction is run again as a whole after a retryable error such as a deadlock.
+func (c *Client) WithTransactionOptionsCtx(ctx context.Context, opts *TxOptions, fn func(tx *Client) error) error {
+	if c.tx == nil && c.retryPolicy != nil && c.retryPolicy.ReplayTransactions {
+		return c.retryPolicy.Do(ctx, func() error {
+			return c.runTransaction(ctx, opts, fn)
+		})
+	}
+
+	return c.runTransaction(ctx, opts, fn)
+}
+
+func (c *Client) runTransaction(ctx context.Context, opts *TxOptions, fn func(tx *Client) error) error {
+	err := c.BeginWithOptionsCtx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = c.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(c)
+	if err != nil {
+		_ = c.Rollback()
+		return err
+	}
 
-		return c.tx.Rollback()
+	return c.Commit()
+}
+
+// retry runs fn under the retry policy when enabled, statements of a transaction are never retried
+// on their own since a deadlock or a lost connection ends the whole transaction.
+func (c *Client) retry(ctx context.Context, enabled bool, fn func() error) error {
+	if !enabled || c.retryPolicy == nil || c.tx != nil {
+		return fn()
 	}
 
-	return errors.New("Not in transaction")
+	return c.retryPolicy.Do(ctx, fn)
+}
+
+// useStmtCache tells whether a statement goes through the statement cache,
+// statements without args such as BEGIN or SAVEPOINT are not worth preparing.
+func (c *Client) useStmtCache(args []interface{}) bool {
+	return c.stmtCache != nil && len(args) > 0
 }
 
-func (c *Client) log(query string, args ...interface{}) {
-	query = strings.Replace(query, "?", "%s", -1)
-	vs := make([]interface{}, len(args))
+// withStmt runs fn with the cached statement of query, bound to the transaction if there is one.
+// When the server no longer knows the statement it is prepared again and fn retried once.
+func (c *Client) withStmt(ctx context.Context, query string, fn func(stmt *sql.Stmt) error) error {
+	for retried := false; ; retried = true {
+		stmt, err := c.stmtCache.get(ctx, c.db, query)
+		if err != nil {
+			return err
+		}
+
+		if c.tx != nil {
+			stmt = c.tx.StmtContext(ctx, stmt)
+		}
 
-	for i, v := range args {
-		s := fmt.Sprint(v)
-		switch v.(type) {
-		case string:
-			vs[i] = "'" + s + "'"
-		default:
-			vs[i] = s
+		err = fn(stmt)
+		if !retried && isUnknownStmtError(err) {
+			c.stmtCache.evict(query)
+			continue
 		}
+
+		return err
+	}
+}
+
+func (c *Client) setLockWaitTimeout(ctx context.Context, seconds int64) error {
+	var saved int64
+	err := c.QueryRowContext(ctx, "SELECT @@SESSION.innodb_lock_wait_timeout").Scan(&saved)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.ExecContext(ctx, "SET SESSION innodb_lock_wait_timeout = ?", seconds)
+	if err != nil {
+		return err
+	}
+
+	c.savedLockWaitTimeout = saved
+	return nil
+}
+
+// restoreLockWaitTimeout puts back the session value before the connection is released by COMMIT or ROLLBACK,
+// it is best effort since the transaction has to end either way.
+func (c *Client) restoreLockWaitTimeout() {
+	if c.savedLockWaitTimeout == 0 {
+		return
+	}
+
+	_, _ = c.Exec("SET SESSION innodb_lock_wait_timeout = ?", c.savedLockWaitTimeout)
+	c.savedLockWaitTimeout = 0
+}
+
+func (c *Client) savepointName() string {
+	return "sp_" + strconv.Itoa(c.txDepth)
+}
+
+// resetTx rolls back the whole transaction, including every nested level.
+func (c *Client) resetTx() {
+	if c.tx == nil {
+		return
+	}
+
+	c.txDepth = 0
+	_ = c.Rollback()
+}
+
+// log writes op once it ran: at error level when it failed, at warning level when it took Config.SlowThreshold
+// or more, else at Config.LogLevel for one out of Config.LogSampling statements.
+// The rows logged are the rows affected by an exec, the rows of a query are not read yet at this point.
+func (c *Client) log(ctx context.Context, op *Operation) {
+	level := c.config.LogLevel
+	if op.Err != nil {
+		level = golog.LevelError
+	} else if c.config.SlowThreshold > 0 && op.Duration >= c.config.SlowThreshold {
+		level = golog.LevelWarning
+	} else if !c.sampled() {
+		return
 	}
 
-	query = fmt.Sprintf(query, vs...)
+	args := op.Args
+	if c.config.Redactor != nil {
+		args = c.config.Redactor.RedactArgs(op.Query, args)
+	}
+
+	loc := c.config.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	query := interpolateForDisplay(op.Query, args, loc)
+
+	traceId := TraceIdFromContext(ctx)
+	if traceId == nil {
+		traceId = c.traceId
+	}
 
 	var msg []byte
 	if c.logPrefix != nil {
-		msg = gomisc.AppendBytes(c.traceId, []byte("\t"), c.logPrefix, []byte(query))
+		msg = gomisc.AppendBytes(traceId, []byte("\t"), c.logPrefix, []byte(query))
 	} else {
-		msg = gomisc.AppendBytes(c.traceId, []byte("\t"), []byte(query))
+		msg = gomisc.AppendBytes(traceId, []byte("\t"), []byte(query))
+	}
+
+	msg = append(msg, "\tduration="+op.Duration.String()...)
+	if op.Kind == OpExec && op.Err == nil && op.Result != nil {
+		if ra, err := op.Result.RowsAffected(); err == nil {
+			msg = append(msg, "\trows="+strconv.FormatInt(ra, 10)...)
+		}
+	}
+	if op.Err != nil {
+		msg = append(msg, "\terr="+op.Err.Error()...)
+	}
+	if caller := callerLocation(); caller != "" {
+		msg = append(msg, "\tcaller="+caller...)
+	}
+
+	c.logger.Log(level, msg)
+}
+
+func (c *Client) sampled() bool {
+	if c.config.LogSampling <= 1 {
+		return true
 	}
 
-	c.logger.Log(c.config.LogLevel, msg)
+	return atomic.AddUint64(&c.logCnt, 1)%uint64(c.config.LogSampling) == 1
+}
+
+var packagePath = reflect.TypeOf(Client{}).PkgPath()
+
+// callerLocation returns file:line of the first caller outside this package, tests of the package count as callers.
+func callerLocation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+
+		inPackage := strings.HasPrefix(frame.Function, packagePath+".") && !strings.HasSuffix(frame.File, "_test.go")
+		if !inPackage && !strings.HasPrefix(frame.Function, "runtime.") {
+			return filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
 }
@@ -1,9 +1,16 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-jar/golog"
 )
 
 var client *Client
@@ -77,3 +84,123 @@ func TestClient_Trans(t *testing.T) {
 		fmt.Println(err.Error())
 	}
 }
+
+func TestClient_QueryContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ctx = ContextWithTraceId(ctx, []byte("ctx-trace-id"))
+	raws, err := client.QueryContext(ctx, "select * from people where name in (?, ?)", "a", "b")
+
+	if err != nil {
+		fmt.Println(err.Error())
+	} else {
+		for raws.Next() {
+			item := new(DemoItem)
+			err = raws.Scan(&item.Id, &item.Name, &item.Age)
+			if err != nil {
+				fmt.Println(err.Error())
+			} else {
+				fmt.Println(item)
+			}
+		}
+	}
+}
+
+func TestClient_WithTransaction(t *testing.T) {
+	err := client.WithTransaction(func(tx *Client) error {
+		_, err := tx.Exec("update people set age = ? where name = ?", 5, "a")
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("update people set age = ? where name = ?", 6, "b")
+		return err
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+
+	err = client.WithTransaction(func(tx *Client) error {
+		tx.Exec("update people set age = ? where name = ?", 7, "a")
+		return errors.New("rollback")
+	})
+	fmt.Println(err, client.InTransaction())
+}
+
+func TestClient_NestedTrans(t *testing.T) {
+	client.Begin()
+	client.Exec("update people set age = ? where name = ?", 8, "a")
+
+	client.Begin()
+	client.Exec("update people set age = ? where name = ?", 9, "b")
+	fmt.Println(client.TransactionDepth())
+	client.Rollback()
+
+	err := client.Commit()
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+	fmt.Println(client.TransactionDepth())
+}
+
+func TestClient_BeginWithOptions(t *testing.T) {
+	opts := &TxOptions{
+		Isolation:       sql.LevelReadCommitted,
+		LockWaitTimeout: time.Second * 3,
+	}
+
+	err := client.BeginWithOptions(opts)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	client.Exec("update people set age = ? where name = ?", 10, "a")
+	err = client.Commit()
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+type recordLogger struct {
+	golog.NoopLogger
+
+	levels []int
+	msgs   []string
+}
+
+func (l *recordLogger) Log(level int, msg []byte) error {
+	l.levels = append(l.levels, level)
+	l.msgs = append(l.msgs, string(msg))
+	return nil
+}
+
+func TestClient_SlowLog(t *testing.T) {
+	logger := new(recordLogger)
+	config := NewConfig("root", "passwd", "127.0.0.1", "3306", "demo")
+	config.SlowThreshold = time.Second
+	config.LogSampling = 2
+
+	client, _ := NewClient(config, logger)
+	for _, d := range []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond, time.Second * 2} {
+		client.log(context.Background(), &Operation{Kind: OpQuery, Query: "select * from people where id = ?", Args: []interface{}{1}, Duration: d})
+	}
+
+	if len(logger.msgs) != 3 {
+		t.Fatal("unexpected logs", logger.msgs)
+	}
+	if logger.levels[2] != golog.LevelWarning || !strings.Contains(logger.msgs[2], "duration=2s") {
+		t.Error("the slow statement should be logged at warning level", logger.msgs[2])
+	}
+	if !strings.Contains(logger.msgs[0], "caller=client_test.go:") {
+		t.Error("unexpected caller", logger.msgs[0])
+	}
+
+	for i := 0; i < 2; i++ {
+		client.log(context.Background(), &Operation{Kind: OpExec, Query: "delete from people", Err: ErrLockTimeout, Duration: time.Millisecond})
+	}
+	if len(logger.msgs) != 5 || logger.levels[3] != golog.LevelError || logger.levels[4] != golog.LevelError {
+		t.Error("failed statements should always be logged at error level", logger.msgs)
+	}
+}
@@ -0,0 +1,207 @@
+package mysql
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type replicaPool struct {
+	name   string
+	pool   *Pool
+	weight int
+
+	ejected   bool
+	lag       time.Duration
+	err       error
+	failures  int
+	checkedAt time.Time
+}
+
+// ReplicaStatus is the state of a replica as last seen by a ReplicaChecker.
+type ReplicaStatus struct {
+	Name string
+	// Lag is the last lag measured, it is kept while the checks fail.
+	Lag     time.Duration
+	Ejected bool
+	Err     error
+	// Failures counts the checks failed in a row.
+	Failures  int
+	CheckedAt time.Time
+}
+
+// ClusterPool holds the pool of a primary and the weighted pools of its replicas,
+// writes go to the primary and reads are spread over the replicas.
+type ClusterPool struct {
+	primary *Pool
+
+	lock     sync.RWMutex
+	replicas []*replicaPool
+}
+
+func NewClusterPool(primary *Pool) *ClusterPool {
+	return &ClusterPool{
+		primary: primary,
+	}
+}
+
+// AddReplica adds a replica pool named name, a replica with weight 2 receives twice the reads of one with weight 1.
+func (cp *ClusterPool) AddReplica(name string, pool *Pool, weight int) *ClusterPool {
+	if weight <= 0 {
+		weight = 1
+	}
+
+	cp.lock.Lock()
+	cp.replicas = append(cp.replicas, &replicaPool{
+		name:   name,
+		pool:   pool,
+		weight: weight,
+	})
+	cp.lock.Unlock()
+
+	return cp
+}
+
+func (cp *ClusterPool) Primary() *Pool {
+	return cp.primary
+}
+
+// Replica picks a replica pool at random by weight, ejected replicas are skipped
+// and it falls back to the primary when no replica is left.
+func (cp *ClusterPool) Replica() *Pool {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+
+	total := 0
+	for _, rp := range cp.replicas {
+		if !rp.ejected {
+			total += rp.weight
+		}
+	}
+	if total == 0 {
+		return cp.primary
+	}
+
+	n := rand.Intn(total)
+	for _, rp := range cp.replicas {
+		if rp.ejected {
+			continue
+		}
+		if n < rp.weight {
+			return rp.pool
+		}
+		n -= rp.weight
+	}
+
+	return cp.primary
+}
+
+func (cp *ClusterPool) ReplicaNames() []string {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+
+	names := make([]string, len(cp.replicas))
+	for i, rp := range cp.replicas {
+		names[i] = rp.name
+	}
+
+	return names
+}
+
+func (cp *ClusterPool) ReplicaStatus(name string) (*ReplicaStatus, bool) {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+
+	for _, rp := range cp.replicas {
+		if rp.name == name {
+			return rp.status(), true
+		}
+	}
+
+	return nil, false
+}
+
+func (cp *ClusterPool) ReplicaStatuses() []*ReplicaStatus {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+
+	statuses := make([]*ReplicaStatus, len(cp.replicas))
+	for i, rp := range cp.replicas {
+		statuses[i] = rp.status()
+	}
+
+	return statuses
+}
+
+// ReplicaLag returns the last measured lag of the named replica and whether it is out of rotation,
+// ok is false for an unknown replica.
+func (cp *ClusterPool) ReplicaLag(name string) (lag time.Duration, ejected bool, ok bool) {
+	status, find := cp.ReplicaStatus(name)
+	if !find {
+		return 0, false, false
+	}
+
+	return status.Lag, status.Ejected, true
+}
+
+func (cp *ClusterPool) replicaPools() map[string]*Pool {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+
+	pools := make(map[string]*Pool, len(cp.replicas))
+	for _, rp := range cp.replicas {
+		pools[rp.name] = rp.pool
+	}
+
+	return pools
+}
+
+// updateReplica records the result of a check: a lag above maxLag ejects the replica at once, a failed check only
+// once maxFailures checks failed in a row, and a check within maxLag puts it back. It returns the recorded status
+// and whether the replica changed between ejected and in rotation.
+func (cp *ClusterPool) updateReplica(name string, lag time.Duration, err error, maxLag time.Duration, maxFailures int) (*ReplicaStatus, bool) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	for _, rp := range cp.replicas {
+		if rp.name != name {
+			continue
+		}
+
+		ejected := rp.ejected
+		if err != nil {
+			rp.failures++
+			if rp.failures >= maxFailures {
+				ejected = true
+			}
+		} else {
+			rp.failures = 0
+			rp.lag = lag
+			ejected = lag > maxLag
+			if ejected {
+				err = errors.New("lag " + lag.String() + " exceeds " + maxLag.String())
+			}
+		}
+
+		changed := rp.ejected != ejected
+		rp.err = err
+		rp.ejected = ejected
+		rp.checkedAt = time.Now()
+
+		return rp.status(), changed
+	}
+
+	return nil, false
+}
+
+func (rp *replicaPool) status() *ReplicaStatus {
+	return &ReplicaStatus{
+		Name:      rp.name,
+		Lag:       rp.lag,
+		Ejected:   rp.ejected,
+		Err:       rp.err,
+		Failures:  rp.failures,
+		CheckedAt: rp.checkedAt,
+	}
+}
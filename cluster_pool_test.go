@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClusterPoolReplica(t *testing.T) {
+	primary := NewPool(&PoolConfig{NewClientFunc: newMysqlTestClient})
+	cp := NewClusterPool(primary)
+
+	if cp.Replica() != primary {
+		t.Error("replica should fall back to the primary")
+	}
+
+	replica1 := NewPool(&PoolConfig{NewClientFunc: newMysqlTestClient})
+	replica2 := NewPool(&PoolConfig{NewClientFunc: newMysqlTestClient})
+	cp.AddReplica("replica1", replica1, 3).AddReplica("replica2", replica2, 1)
+
+	picked := map[*Pool]int{}
+	for i := 0; i < 1000; i++ {
+		picked[cp.Replica()]++
+	}
+
+	if picked[primary] != 0 {
+		t.Error("reads should not go to the primary when replicas exist")
+	}
+	if picked[replica1] <= picked[replica2] {
+		t.Error("replica1 should receive more reads than replica2", picked[replica1], picked[replica2])
+	}
+}
+
+func TestClusterPoolEjectReplica(t *testing.T) {
+	primary := NewPool(&PoolConfig{NewClientFunc: newMysqlTestClient})
+	replica := NewPool(&PoolConfig{NewClientFunc: newMysqlTestClient})
+	cp := NewClusterPool(primary).AddReplica("replica", replica, 1)
+
+	if _, changed := cp.updateReplica("replica", time.Minute, nil, 10*time.Second, 3); !changed {
+		t.Error("a lag above the max should eject the replica at once")
+	}
+	if cp.Replica() != primary {
+		t.Error("reads should fall back to the primary when every replica is ejected")
+	}
+	if lag, ejected, ok := cp.ReplicaLag("replica"); !ok || !ejected || lag != time.Minute {
+		t.Error("an ejected replica should keep its lag", lag, ejected, ok)
+	}
+
+	cp.updateReplica("replica", time.Second, nil, 10*time.Second, 3)
+	if cp.Replica() != replica {
+		t.Error("a recovered replica should be back in rotation")
+	}
+	if lag, ejected, ok := cp.ReplicaLag("replica"); !ok || ejected || lag != time.Second {
+		t.Error("unexpected lag", lag, ejected)
+	}
+
+	timeout := errors.New("i/o timeout")
+	for i := 1; i <= 3; i++ {
+		status, changed := cp.updateReplica("replica", 0, timeout, 10*time.Second, 3)
+		if changed != (i == 3) || status.Failures != i {
+			t.Error("a replica should be ejected after 3 failed checks in a row", i, changed, status.Failures)
+		}
+	}
+	if lag, ejected, _ := cp.ReplicaLag("replica"); !ejected || lag != time.Second {
+		t.Error("failed checks should keep the last lag", lag, ejected)
+	}
+}
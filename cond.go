@@ -0,0 +1,109 @@
+package mysql
+
+// CondExpr is a condition of a where or having clause: a *QueryItem, or a group made by And, Or and Not.
+type CondExpr interface {
+	buildCond(qb *QueryBuilder, nested bool)
+	isEmptyCond() bool
+}
+
+type condGroup struct {
+	andOr string
+	conds []CondExpr
+}
+
+type condNot struct {
+	cond CondExpr
+}
+
+// And joins conds with and, nil and empty conds are skipped. A group nested in another one is parenthesized.
+func And(conds ...CondExpr) CondExpr {
+	return &condGroup{andOr: "and", conds: conds}
+}
+
+// Or joins conds with or, nil and empty conds are skipped. A group nested in another one is parenthesized.
+func Or(conds ...CondExpr) CondExpr {
+	return &condGroup{andOr: "or", conds: conds}
+}
+
+func Not(cond CondExpr) CondExpr {
+	return &condNot{cond: cond}
+}
+
+func (item *QueryItem) buildCond(qb *QueryBuilder, nested bool) {
+	qb.buildConditionWhere(item)
+}
+
+func (item *QueryItem) isEmptyCond() bool {
+	return item == nil
+}
+
+func (g *condGroup) buildCond(qb *QueryBuilder, nested bool) {
+	var conds []CondExpr
+	for _, cond := range g.conds {
+		if !isEmptyCond(cond) {
+			conds = append(conds, cond)
+		}
+	}
+
+	if len(conds) == 1 {
+		conds[0].buildCond(qb, nested)
+		return
+	}
+
+	if nested {
+		qb.query += "("
+	}
+	for i, cond := range conds {
+		if i > 0 {
+			qb.query += " " + g.andOr + " "
+		}
+		cond.buildCond(qb, true)
+	}
+	if nested {
+		qb.query += ")"
+	}
+}
+
+func (g *condGroup) isEmptyCond() bool {
+	for _, cond := range g.conds {
+		if !isEmptyCond(cond) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n *condNot) buildCond(qb *QueryBuilder, nested bool) {
+	qb.query += "not ("
+	n.cond.buildCond(qb, false)
+	qb.query += ")"
+}
+
+func (n *condNot) isEmptyCond() bool {
+	return isEmptyCond(n.cond)
+}
+
+func isEmptyCond(cond CondExpr) bool {
+	return cond == nil || cond.isEmptyCond()
+}
+
+// Where adds a where clause made of conds joined with and, e.g. Where(NewCondition("a", CondEqual, 1), Or(b, c)).
+func (qb *QueryBuilder) Where(conds ...CondExpr) *QueryBuilder {
+	return qb.buildCondClause(" where ", And(conds...))
+}
+
+// Having adds a having clause made of conds joined with and.
+func (qb *QueryBuilder) Having(conds ...CondExpr) *QueryBuilder {
+	return qb.buildCondClause(" having ", And(conds...))
+}
+
+func (qb *QueryBuilder) buildCondClause(clause string, cond CondExpr) *QueryBuilder {
+	if isEmptyCond(cond) {
+		return qb
+	}
+
+	qb.query += clause
+	cond.buildCond(qb, false)
+	return qb
+}
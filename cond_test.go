@@ -0,0 +1,45 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhereNested(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Select(TABLE_NAME, "*").
+		Where(
+			NewCondition("a", CondEqual, 1),
+			Or(
+				NewCondition("b", CondEqual, 2),
+				And(NewCondition("c", CondLike, "x%"), Not(NewCondition("d", CondIn, []int{3, 4}))),
+			),
+			Or(nil, And()),
+		)
+
+	expect := "select * from people where a = ?  and (b = ?  or (c like ? and not (d in (?, ?))))"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{1, 2, "x%", 3, 4}) {
+		t.Error(qb.Args())
+	}
+}
+
+func TestHavingNested(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Select(TABLE_NAME, "name, count(*) as cnt").
+		Where(Or(NewCondition("age", CondGreater, 10))).
+		GroupBy("name").
+		Having(Not(Or(NewCondition("cnt", CondLess, 2), NewCondition("cnt", CondGreater, 9))))
+
+	expect := "select name, count(*) as cnt from people where age > ?  group by name having not (cnt < ?  or cnt > ? )"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+
+	qb.Select(TABLE_NAME, "*").Where(And(nil), nil)
+	if qb.Query() != "select * from people" {
+		t.Error(qb.Query())
+	}
+}
@@ -17,6 +17,22 @@ type Config struct {
 	*mysql.Config
 
 	LogLevel int
+	// SlowThreshold makes statements taking at least this long be logged at warning level, disabled when 0.
+	SlowThreshold time.Duration
+	// LogSampling logs one out of LogSampling statements which neither failed nor were slow, all of them when <= 1.
+	LogSampling int
+
+	// StmtCacheSize enables the statement cache of the clients created with this config when > 0, see Client.EnableStmtCache.
+	StmtCacheSize int
+
+	// RetryPolicy is set on the clients created with this config, see Client.SetRetryPolicy.
+	RetryPolicy *RetryPolicy
+
+	// Middlewares are installed on the clients created with this config, before the ones added by Client.Use.
+	Middlewares []Middleware
+
+	// Redactor masks the sensitive args in the logged statements, nothing is masked when nil.
+	Redactor *Redactor
 }
 
 func NewConfig(user, passwd, host, port, dbName string) *Config {
@@ -0,0 +1,40 @@
+package mysql
+
+import (
+	"context"
+)
+
+type contextKey int
+
+const (
+	traceIdContextKey contextKey = iota
+	spanContextKey
+)
+
+// ContextWithTraceId returns a copy of ctx carrying traceId, which takes precedence over Client.SetTraceId in logs.
+func ContextWithTraceId(ctx context.Context, traceId []byte) context.Context {
+	return context.WithValue(ctx, traceIdContextKey, traceId)
+}
+
+func TraceIdFromContext(ctx context.Context) []byte {
+	if ctx == nil {
+		return nil
+	}
+
+	traceId, _ := ctx.Value(traceIdContextKey).([]byte)
+	return traceId
+}
+
+// ContextWithSpan returns a copy of ctx carrying span, the parent of the spans started from it.
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanContextKey, span)
+}
+
+func SpanFromContext(ctx context.Context) Span {
+	if ctx == nil {
+		return nil
+	}
+
+	span, _ := ctx.Value(spanContextKey).(Span)
+	return span
+}
@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 )
 
@@ -12,6 +13,9 @@ type ExecResult struct {
 
 type Dao struct {
 	*Client
+
+	replica      *Client
+	forcePrimary bool
 }
 
 func NewDao(client *Client) *Dao {
@@ -20,115 +24,229 @@ func NewDao(client *Client) *Dao {
 	}
 }
 
+// NewClusterDao returns a dao which writes through primary and sends the Select* reads to replica.
+func NewClusterDao(primary, replica *Client) *Dao {
+	return &Dao{
+		Client:  primary,
+		replica: replica,
+	}
+}
+
+func (d *Dao) SetReplica(replica *Client) *Dao {
+	d.replica = replica
+	return d
+}
+
+func (d *Dao) Replica() *Client {
+	return d.replica
+}
+
+// SetForcePrimary makes reads go to the primary, e.g. to read your own writes.
+func (d *Dao) SetForcePrimary(forcePrimary bool) *Dao {
+	d.forcePrimary = forcePrimary
+	return d
+}
+
+// reader returns the client for reads, reads stick to the primary inside a transaction.
+func (d *Dao) reader() *Client {
+	if d.replica == nil || d.forcePrimary {
+		return d.Client
+	}
+	if d.Client != nil && d.Client.InTransaction() {
+		return d.Client
+	}
+
+	return d.replica
+}
+
 func (d *Dao) Insert(tableName string, colNames []string, colValues ...[]interface{}) *ExecResult {
+	return d.InsertCtx(context.Background(), tableName, colNames, colValues...)
+}
+
+func (d *Dao) InsertCtx(ctx context.Context, tableName string, colNames []string, colValues ...[]interface{}) *ExecResult {
 	qb := new(QueryBuilder)
 	qb.Insert(tableName, colNames...).
 		Values(colValues...)
 
-	return GetExecResult(d.Exec(qb.Query(), qb.Args()...))
+	return GetExecResult(d.ExecContext(ctx, qb.Query(), qb.Args()...))
 }
 
 func (d *Dao) DeleteById(tableName string, id int64) *ExecResult {
+	return d.DeleteByIdCtx(context.Background(), tableName, id)
+}
+
+func (d *Dao) DeleteByIdCtx(ctx context.Context, tableName string, id int64) *ExecResult {
 	qb := new(QueryBuilder)
 	qb.Delete(tableName).
 		WhereAnd(NewCondition("id", CondEqual, id))
 
-	return GetExecResult(d.Exec(qb.Query(), qb.Args()...))
+	return GetExecResult(d.ExecContext(ctx, qb.Query(), qb.Args()...))
 }
 
 func (d *Dao) DeleteByIds(tableName string, ids ...int64) *ExecResult {
+	return d.DeleteByIdsCtx(context.Background(), tableName, ids...)
+}
+
+func (d *Dao) DeleteByIdsCtx(ctx context.Context, tableName string, ids ...int64) *ExecResult {
 	qb := new(QueryBuilder)
 	qb.Delete(tableName).
 		WhereAnd(NewCondition("id", CondIn, ids))
 
-	return GetExecResult(d.Exec(qb.Query(), qb.Args()...))
+	return GetExecResult(d.ExecContext(ctx, qb.Query(), qb.Args()...))
 }
 
 func (d *Dao) UpdateById(tableName string, id int64, pairs ...*QueryItem) *ExecResult {
+	return d.UpdateByIdCtx(context.Background(), tableName, id, pairs...)
+}
+
+func (d *Dao) UpdateByIdCtx(ctx context.Context, tableName string, id int64, pairs ...*QueryItem) *ExecResult {
 	qb := new(QueryBuilder)
 	qb.Update(tableName).
 		Set(pairs...).
 		WhereAnd(NewCondition("id", CondEqual, id))
 
-	return GetExecResult(d.Exec(qb.Query(), qb.Args()...))
+	return GetExecResult(d.ExecContext(ctx, qb.Query(), qb.Args()...))
 }
 
 func (d *Dao) UpdateByIds(tableName string, ids []int64, pairs ...*QueryItem) *ExecResult {
+	return d.UpdateByIdsCtx(context.Background(), tableName, ids, pairs...)
+}
+
+func (d *Dao) UpdateByIdsCtx(ctx context.Context, tableName string, ids []int64, pairs ...*QueryItem) *ExecResult {
 	qb := new(QueryBuilder)
 	qb.Update(tableName).
 		Set(pairs...).
 		WhereAnd(NewCondition("id", CondIn, ids))
 
-	return GetExecResult(d.Exec(qb.Query(), qb.Args()...))
+	return GetExecResult(d.ExecContext(ctx, qb.Query(), qb.Args()...))
 }
 
 func (d *Dao) SelectById(tableName, what string, id int64) *sql.Row {
+	return d.SelectByIdCtx(context.Background(), tableName, what, id)
+}
+
+func (d *Dao) SelectByIdCtx(ctx context.Context, tableName, what string, id int64) *sql.Row {
 	qb := new(QueryBuilder)
 	qb.Select(tableName, what).
 		WhereAnd(NewCondition("id", CondEqual, id))
 
-	return d.QueryRow(qb.Query(), qb.Args()...)
+	return d.reader().QueryRowContext(ctx, qb.Query(), qb.Args()...)
 }
 
 func (d *Dao) SelectByIds(tableName, what, orderBy string, ids ...int64) (*sql.Rows, error) {
+	return d.SelectByIdsCtx(context.Background(), tableName, what, orderBy, ids...)
+}
+
+func (d *Dao) SelectByIdsCtx(ctx context.Context, tableName, what, orderBy string, ids ...int64) (*sql.Rows, error) {
 	qb := new(QueryBuilder)
 	qb.Select(tableName, what).
 		WhereAnd(NewCondition("id", CondIn, ids)).
 		OrderBy(orderBy)
 
-	return d.Query(qb.Query(), qb.Args()...)
+	return d.reader().QueryContext(ctx, qb.Query(), qb.Args()...)
 }
 
 func (d *Dao) SelectByIdsLimit(tableName, what, orderBy string, offset, limit int64, ids ...int64) (*sql.Rows, error) {
+	return d.SelectByIdsLimitCtx(context.Background(), tableName, what, orderBy, offset, limit, ids...)
+}
+
+func (d *Dao) SelectByIdsLimitCtx(ctx context.Context, tableName, what, orderBy string, offset, limit int64, ids ...int64) (*sql.Rows, error) {
 	qb := new(QueryBuilder)
 	qb.Select(tableName, what).
 		WhereAnd(NewCondition("id", CondIn, ids)).
 		OrderBy(orderBy).
 		Limit(offset, limit)
 
-	return d.Query(qb.Query(), qb.Args()...)
+	return d.reader().QueryContext(ctx, qb.Query(), qb.Args()...)
 }
 
 func (d *Dao) SelectTotalAnd(tableName string, conditions ...*QueryItem) (int64, error) {
+	return d.SelectTotalAndCtx(context.Background(), tableName, conditions...)
+}
+
+func (d *Dao) SelectTotalAndCtx(ctx context.Context, tableName string, conditions ...*QueryItem) (int64, error) {
 	qb := new(QueryBuilder)
 	qb.Select(tableName, "count(1)").
 		WhereAnd(conditions...)
 
 	var total int64
-	err := d.QueryRow(qb.Query(), qb.Args()...).Scan(&total)
+	err := d.reader().QueryRowContext(ctx, qb.Query(), qb.Args()...).Scan(&total)
 
-	return total, err
+	return total, ClassifyError(err)
 }
 
 func (d *Dao) SelectTotalOr(tableName string, conditions ...*QueryItem) (int64, error) {
+	return d.SelectTotalOrCtx(context.Background(), tableName, conditions...)
+}
+
+func (d *Dao) SelectTotalOrCtx(ctx context.Context, tableName string, conditions ...*QueryItem) (int64, error) {
 	qb := new(QueryBuilder)
 	qb.Select(tableName, "count(1)").
 		WhereOr(conditions...)
 
 	var total int64
-	err := d.QueryRow(qb.Query(), qb.Args()...).Scan(&total)
+	err := d.reader().QueryRowContext(ctx, qb.Query(), qb.Args()...).Scan(&total)
 
-	return total, err
+	return total, ClassifyError(err)
 }
 
 func (d *Dao) SimpleSelectAnd(tableName, what, orderBy string, offset, limit int64, conditions ...*QueryItem) (*sql.Rows, error) {
+	return d.SimpleSelectAndCtx(context.Background(), tableName, what, orderBy, offset, limit, conditions...)
+}
+
+func (d *Dao) SimpleSelectAndCtx(ctx context.Context, tableName, what, orderBy string, offset, limit int64, conditions ...*QueryItem) (*sql.Rows, error) {
 	qb := new(QueryBuilder)
 	qb.Select(tableName, what).
 		WhereAnd(conditions...).
 		OrderBy(orderBy).
 		Limit(offset, limit)
 
-	return d.Query(qb.Query(), qb.Args()...)
+	return d.reader().QueryContext(ctx, qb.Query(), qb.Args()...)
 }
 
 func (d *Dao) SimpleSelectOr(tableName, what, orderBy string, offset, limit int64, conditions ...*QueryItem) (*sql.Rows, error) {
+	return d.SimpleSelectOrCtx(context.Background(), tableName, what, orderBy, offset, limit, conditions...)
+}
+
+func (d *Dao) SimpleSelectOrCtx(ctx context.Context, tableName, what, orderBy string, offset, limit int64, conditions ...*QueryItem) (*sql.Rows, error) {
 	qb := new(QueryBuilder)
 	qb.Select(tableName, what).
 		WhereOr(conditions...).
 		OrderBy(orderBy).
 		Limit(offset, limit)
 
-	return d.Query(qb.Query(), qb.Args()...)
+	return d.reader().QueryContext(ctx, qb.Query(), qb.Args()...)
+}
+
+// SelectTotalWhere counts the rows of tableName matching cond, e.g. And(a, Or(b, c)).
+func (d *Dao) SelectTotalWhere(tableName string, cond CondExpr) (int64, error) {
+	return d.SelectTotalWhereCtx(context.Background(), tableName, cond)
+}
+
+func (d *Dao) SelectTotalWhereCtx(ctx context.Context, tableName string, cond CondExpr) (int64, error) {
+	qb := new(QueryBuilder)
+	qb.Select(tableName, "count(1)").
+		Where(cond)
+
+	var total int64
+	err := d.reader().QueryRowContext(ctx, qb.Query(), qb.Args()...).Scan(&total)
+
+	return total, ClassifyError(err)
+}
+
+// SimpleSelectWhere selects the rows of tableName matching cond, e.g. And(a, Or(b, c)).
+func (d *Dao) SimpleSelectWhere(tableName, what, orderBy string, offset, limit int64, cond CondExpr) (*sql.Rows, error) {
+	return d.SimpleSelectWhereCtx(context.Background(), tableName, what, orderBy, offset, limit, cond)
+}
+
+func (d *Dao) SimpleSelectWhereCtx(ctx context.Context, tableName, what, orderBy string, offset, limit int64, cond CondExpr) (*sql.Rows, error) {
+	qb := new(QueryBuilder)
+	qb.Select(tableName, what).
+		Where(cond).
+		OrderBy(orderBy).
+		Limit(offset, limit)
+
+	return d.reader().QueryContext(ctx, qb.Query(), qb.Args()...)
 }
 
 func GetExecResult(result sql.Result, err error) *ExecResult {
@@ -36,6 +36,12 @@ func TestDaoWrite(t *testing.T) {
 	result = dao.UpdateByIds(TABLE_NAME, ids, setItems...)
 	printResult("UpdateByIds: ", result)
 
+	result = dao.IncrementById(TABLE_NAME, 10, "age", 2)
+	printResult("IncrementById: ", result)
+
+	result = dao.Upsert(TABLE_NAME, colNames, [][]interface{}{{10, "dd", 20}}, "age")
+	printResult("Upsert: ", UpsertResultOf(result.RowsAffected))
+
 	result = dao.DeleteById(TABLE_NAME, 13)
 	printResult("DeleteById: ", result)
 
@@ -90,6 +96,22 @@ func TestDaoRead(t *testing.T) {
 
 	total, _ = dao.SelectTotalOr(TABLE_NAME, conditions...)
 	printResult("SelectTotalOr: ", total)
+
+	cond := And(
+		NewCondition("age", CondGreaterEqual, 10),
+		Or(NewCondition("name", CondEqual, "dd"), NewCondition("name", CondEqual, "ee")),
+	)
+
+	fmt.Println("SimpleSelectWhere: ")
+	rows, _ = dao.SimpleSelectWhere(TABLE_NAME, "*", "id desc", 0, 10, cond)
+	for rows.Next() {
+		rows.Scan(&item.Id, &item.Name, &item.Age)
+		fmt.Println(item)
+	}
+	fmt.Println("====================")
+
+	total, _ = dao.SelectTotalWhere(TABLE_NAME, cond)
+	printResult("SelectTotalWhere: ", total)
 }
 
 func printResult(msg string, result interface{}) {
@@ -0,0 +1,390 @@
+package mysql
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jar/golog"
+)
+
+const (
+	DefaultDigestMaxFingerprints = 1000
+	DefaultDigestSamples         = 500
+	DefaultDigestDumpInterval    = time.Minute
+	DefaultDigestDumpTop         = 20
+)
+
+// Fingerprint normalizes query the way pt-query-digest does: comments dropped, whitespace collapsed,
+// unquoted words lower-cased, literals replaced by ? and lists of values, e.g. in (?, ?) or
+// the rows of an insert, collapsed to (?+).
+func Fingerprint(query string) string {
+	var tokens []string
+	space := false
+
+	for _, token := range lexSQL(query) {
+		text := token.text
+
+		switch token.kind {
+		case tokSpace, tokComment:
+			space = len(tokens) > 0
+			continue
+		case tokNumber:
+			text = "?"
+			if dropUnarySign(&tokens) {
+				space = false
+			}
+		case tokString, tokPlaceholder:
+			text = "?"
+		case tokIdent:
+			text = strings.ToLower(text)
+		case tokPunct:
+			if text == ")" && collapseValueList(&tokens) {
+				space = false
+				continue
+			}
+		}
+
+		if space && text != ")" && text != "," && !(len(tokens) > 0 && tokens[len(tokens)-1] == "(") {
+			tokens = append(tokens, " ")
+		}
+		tokens = append(tokens, text)
+		space = false
+	}
+
+	return strings.TrimSuffix(strings.Join(tokens, ""), ";")
+}
+
+// dropUnarySign removes the trailing sign of tokens when it is not preceded by an operand, so that the number
+// following it is replaced with its sign, a = -1 giving a = ? as a = 1 does.
+func dropUnarySign(tokens *[]string) bool {
+	ts := *tokens
+	i := len(ts) - 1
+	if i < 0 || (ts[i] != "-" && ts[i] != "+") {
+		return false
+	}
+
+	prev := ""
+	for j := i - 1; j >= 0; j-- {
+		if ts[j] != " " {
+			prev = ts[j]
+			break
+		}
+	}
+	operand := prev == "?" || prev == ")" || strings.HasPrefix(prev, "`") ||
+		(prev != "" && isIdentByte(prev[0]) && !isReservedWord(prev) && !placeholderSkipKeywords[prev])
+	if operand {
+		return false
+	}
+
+	*tokens = ts[:i]
+	return true
+}
+
+// collapseValueList replaces the trailing "(" ? , ? ... of tokens by (?+), a list following another one
+// and a comma, as the rows of an insert, is dropped. It returns false when the list holds something else than values.
+func collapseValueList(tokens *[]string) bool {
+	ts := *tokens
+	i := len(ts) - 1
+	values := 0
+
+	for ; i >= 0 && ts[i] != "("; i-- {
+		switch ts[i] {
+		case "?":
+			values++
+		case ",", " ":
+		default:
+			return false
+		}
+	}
+	if i < 0 || values == 0 {
+		return false
+	}
+
+	ts = ts[:i]
+	if j := len(ts) - 1; j >= 0 && ts[j] == " " {
+		ts = ts[:j]
+	}
+	if j := len(ts) - 1; j >= 1 && ts[j] == "," && ts[j-1] == "(?+)" {
+		*tokens = ts[:j]
+		return true
+	}
+
+	if len(ts) > 0 {
+		ts = append(ts, " ")
+	}
+	*tokens = append(ts, "(?+)")
+
+	return true
+}
+
+// DigestStats are the statistics of the statements sharing a fingerprint, Rows counts the rows affected by writes.
+type DigestStats struct {
+	Fingerprint string
+	Example     string
+
+	Count  int64
+	Errors int64
+	Rows   int64
+
+	TotalLatency time.Duration
+	AvgLatency   time.Duration
+	P95Latency   time.Duration
+	MaxLatency   time.Duration
+}
+
+type digestEntry struct {
+	stats   DigestStats
+	samples []time.Duration
+}
+
+// DigestCollector gathers DigestStats by fingerprint, feed it with DigestMiddleware.
+// P95Latency is computed from a uniform sample of the latencies.
+type DigestCollector struct {
+	lock            sync.Mutex
+	entries         map[string]*digestEntry
+	maxFingerprints int
+	maxSamples      int
+	rand            *rand.Rand
+}
+
+func NewDigestCollector() *DigestCollector {
+	return &DigestCollector{
+		entries:         make(map[string]*digestEntry),
+		maxFingerprints: DefaultDigestMaxFingerprints,
+		maxSamples:      DefaultDigestSamples,
+		rand:            rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetMaxFingerprints bounds the number of fingerprints kept, the statements of new ones are ignored once it is reached.
+func (dc *DigestCollector) SetMaxFingerprints(max int) *DigestCollector {
+	dc.maxFingerprints = max
+	return dc
+}
+
+func (dc *DigestCollector) SetMaxSamples(max int) *DigestCollector {
+	dc.maxSamples = max
+	return dc
+}
+
+// Observe records a statement.
+func (dc *DigestCollector) Observe(query string, latency time.Duration, rows int64, err error) {
+	fingerprint := Fingerprint(query)
+
+	dc.lock.Lock()
+	defer dc.lock.Unlock()
+
+	entry, ok := dc.entries[fingerprint]
+	if !ok {
+		if len(dc.entries) >= dc.maxFingerprints {
+			return
+		}
+
+		entry = &digestEntry{stats: DigestStats{Fingerprint: fingerprint, Example: query}}
+		dc.entries[fingerprint] = entry
+	}
+
+	s := &entry.stats
+	s.Count++
+	s.Rows += rows
+	if err != nil {
+		s.Errors++
+	}
+	s.TotalLatency += latency
+	if latency > s.MaxLatency {
+		s.MaxLatency = latency
+	}
+
+	// reservoir sampling
+	if len(entry.samples) < dc.maxSamples {
+		entry.samples = append(entry.samples, latency)
+	} else if i := dc.rand.Int63n(s.Count); i < int64(dc.maxSamples) {
+		entry.samples[i] = latency
+	}
+}
+
+// Stats returns the statistics of every fingerprint, the heaviest total latency first.
+func (dc *DigestCollector) Stats() []*DigestStats {
+	dc.lock.Lock()
+	list := make([]*DigestStats, 0, len(dc.entries))
+	for _, entry := range dc.entries {
+		stats := entry.stats
+		stats.AvgLatency = stats.TotalLatency / time.Duration(stats.Count)
+		stats.P95Latency = percentile(entry.samples, 0.95)
+		list = append(list, &stats)
+	}
+	dc.lock.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TotalLatency != list[j].TotalLatency {
+			return list[i].TotalLatency > list[j].TotalLatency
+		}
+		return list[i].Fingerprint < list[j].Fingerprint
+	})
+
+	return list
+}
+
+// Top returns the n fingerprints with the heaviest total latency.
+func (dc *DigestCollector) Top(n int) []*DigestStats {
+	list := dc.Stats()
+	if n >= 0 && n < len(list) {
+		list = list[:n]
+	}
+
+	return list
+}
+
+func (dc *DigestCollector) Reset() {
+	dc.lock.Lock()
+	dc.entries = make(map[string]*digestEntry)
+	dc.lock.Unlock()
+}
+
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	i := int(float64(len(sorted))*p+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	return sorted[i]
+}
+
+// DigestMiddleware feeds collector with the statements executed by a client, transaction operations excluded.
+func DigestMiddleware(collector *DigestCollector) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			err := next(ctx, op)
+
+			switch op.Kind {
+			case OpExec, OpQuery, OpQueryRow:
+			default:
+				return err
+			}
+			if op.Start.IsZero() {
+				return err
+			}
+
+			var rows int64
+			if op.Kind == OpExec && op.Err == nil && op.Result != nil {
+				rows, _ = op.Result.RowsAffected()
+			}
+			collector.Observe(op.Query, op.Duration, rows, op.Err)
+
+			return err
+		}
+	}
+}
+
+// DigestDumper periodically logs the heaviest fingerprints of a DigestCollector.
+type DigestDumper struct {
+	collector *DigestCollector
+	interval  time.Duration
+	top       int
+	reset     bool
+	logger    golog.ILogger
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewDigestDumper(collector *DigestCollector, interval time.Duration, top int) *DigestDumper {
+	if interval <= 0 {
+		interval = DefaultDigestDumpInterval
+	}
+	if top <= 0 {
+		top = DefaultDigestDumpTop
+	}
+
+	return &DigestDumper{
+		collector: collector,
+		interval:  interval,
+		top:       top,
+		logger:    new(golog.NoopLogger),
+	}
+}
+
+func (dd *DigestDumper) SetLogger(logger golog.ILogger) *DigestDumper {
+	if logger == nil {
+		logger = new(golog.NoopLogger)
+	}
+
+	dd.logger = logger
+	return dd
+}
+
+// SetReset makes each dump reset the collector, so that it covers the last interval only.
+func (dd *DigestDumper) SetReset(reset bool) *DigestDumper {
+	dd.reset = reset
+	return dd
+}
+
+func (dd *DigestDumper) Start() {
+	dd.stopCh = make(chan struct{})
+	dd.wg.Add(1)
+
+	go func() {
+		defer dd.wg.Done()
+
+		ticker := time.NewTicker(dd.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-dd.stopCh:
+				return
+			case <-ticker.C:
+				dd.Dump()
+			}
+		}
+	}()
+}
+
+func (dd *DigestDumper) Stop() {
+	if dd.stopCh == nil {
+		return
+	}
+
+	close(dd.stopCh)
+	dd.wg.Wait()
+	dd.stopCh = nil
+}
+
+// Dump logs one line per fingerprint, the heaviest first.
+func (dd *DigestDumper) Dump() {
+	list := dd.collector.Top(dd.top)
+	if dd.reset {
+		dd.collector.Reset()
+	}
+
+	for i, s := range list {
+		msg := "digest #" + strconv.Itoa(i+1) +
+			"\tcount=" + strconv.FormatInt(s.Count, 10) +
+			"\ttotal=" + s.TotalLatency.String() +
+			"\tavg=" + s.AvgLatency.String() +
+			"\tp95=" + s.P95Latency.String() +
+			"\tmax=" + s.MaxLatency.String() +
+			"\trows=" + strconv.FormatInt(s.Rows, 10) +
+			"\terrors=" + strconv.FormatInt(s.Errors, 10) +
+			"\t" + s.Fingerprint
+
+		dd.logger.Log(golog.LevelInfo, []byte(msg))
+	}
+}
@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	for query, expect := range map[string]string{
+		"SELECT *  FROM people\n WHERE id = 10 AND name = 'a''b' -- comment": "select * from people where id = ? and name = ?",
+		"select * from people where id in (1, 2, 3) and name like ?":         "select * from people where id in (?+) and name like ?",
+		"select * from people where age = -1 and id in (-1, 2)":              "select * from people where age = ? and id in (?+)",
+		"update people set age = age - 1 where id = +3":                      "update people set age = age - ? where id = ?",
+		"insert into people (name, age) values (?, ?), (?, ?) , ('c', 7);":   "insert into people (name, age) values (?+)",
+		"select count(*) from `People` where age > -1.5 limit 0, 10":         "select count(*) from `People` where age > ? limit ?, ?",
+		"update people set name = \"x\" /* hint */ where id=?":               "update people set name = ? where id=?",
+	} {
+		if fingerprint := Fingerprint(query); fingerprint != expect {
+			t.Errorf("%q: %q", query, fingerprint)
+		}
+	}
+}
+
+func TestDigestCollector(t *testing.T) {
+	collector := NewDigestCollector()
+	for i := 1; i <= 100; i++ {
+		collector.Observe("select * from people where id = 1", time.Duration(i)*time.Millisecond, 0, nil)
+	}
+	collector.Observe("update people set age = 1 where id in (1, 2)", 7*time.Second, 2, nil)
+	collector.Observe("update people set age = 2 where id in (3)", 3*time.Second, 0, errors.New("lock wait timeout"))
+
+	stats := collector.Stats()
+	if len(stats) != 2 {
+		t.Fatal(len(stats))
+	}
+
+	update, sel := stats[0], stats[1]
+	if update.Fingerprint != "update people set age = ? where id in (?+)" || update.Count != 2 ||
+		update.Rows != 2 || update.Errors != 1 || update.TotalLatency != 10*time.Second ||
+		update.AvgLatency != 5*time.Second || update.MaxLatency != 7*time.Second {
+		t.Error(update)
+	}
+	if sel.Count != 100 || sel.P95Latency != 95*time.Millisecond || sel.MaxLatency != 100*time.Millisecond {
+		t.Error(sel)
+	}
+
+	if top := collector.Top(1); len(top) != 1 || top[0].Fingerprint != update.Fingerprint {
+		t.Error(top)
+	}
+
+	collector.Reset()
+	if len(collector.Stats()) != 0 {
+		t.Error("the collector should be empty")
+	}
+}
@@ -0,0 +1,135 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	ErDupEntry                         = 1062
+	ErNoReferencedRow                  = 1216
+	ErRowIsReferenced                  = 1217
+	ErDataTooLong                      = 1406
+	ErRowIsReferenced2                 = 1451
+	ErNoReferencedRow2                 = 1452
+	ErOptionPreventsStatement          = 1290
+	ErCantExecuteInReadOnlyTransaction = 1792
+	ErReadOnlyMode                     = 1836
+)
+
+// The errors returned by Client, Dao and SimpleOrm match these with errors.Is according to their MySQL error number,
+// errors.As still reaches the underlying *mysql.MySQLError.
+var (
+	ErrDuplicateKey   = errors.New("mysql: duplicate key")
+	ErrForeignKey     = errors.New("mysql: foreign key constraint fails")
+	ErrDeadlock       = errors.New("mysql: deadlock")
+	ErrLockTimeout    = errors.New("mysql: lock wait timeout exceeded")
+	ErrDataTooLong    = errors.New("mysql: data too long")
+	ErrConnectionLost = errors.New("mysql: connection lost")
+	ErrReadOnly       = errors.New("mysql: server is read only")
+)
+
+var errorKinds = map[uint16]error{
+	ErNoReferencedRow:                  ErrForeignKey,
+	ErRowIsReferenced:                  ErrForeignKey,
+	ErRowIsReferenced2:                 ErrForeignKey,
+	ErNoReferencedRow2:                 ErrForeignKey,
+	ErLockDeadlock:                     ErrDeadlock,
+	ErLockWaitTimeout:                  ErrLockTimeout,
+	ErDataTooLong:                      ErrDataTooLong,
+	CrServerGoneError:                  ErrConnectionLost,
+	CrServerLost:                       ErrConnectionLost,
+	ErCantExecuteInReadOnlyTransaction: ErrReadOnly,
+	ErReadOnlyMode:                     ErrReadOnly,
+}
+
+// Error is a classified error, Kind is one of the Err* sentinels.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// DuplicateKeyError is returned for error 1062, Key is the name of the violated index.
+type DuplicateKeyError struct {
+	Entry string
+	Key   string
+	Err   error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+var duplicateEntryRegexp = regexp.MustCompile(`(?s)^Duplicate entry '(.*)' for key '(.*)'$`)
+
+// ClassifyError wraps err so that it matches its Err* sentinel, errors it can not classify are returned unchanged.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	var de *DuplicateKeyError
+	if errors.As(err, &e) || errors.As(err, &de) {
+		return err
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return &Error{Kind: ErrConnectionLost, Err: err}
+	}
+
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return err
+	}
+
+	if me.Number == ErDupEntry {
+		de = &DuplicateKeyError{Err: err}
+		if m := duplicateEntryRegexp.FindStringSubmatch(me.Message); m != nil {
+			de.Entry = m[1]
+			// MySQL 8.0 prefixes the key with its table name
+			de.Key = m[2][strings.LastIndexByte(m[2], '.')+1:]
+		}
+
+		return de
+	}
+
+	// 1290 is raised by every server option preventing a statement, e.g. --secure-file-priv
+	if me.Number == ErOptionPreventsStatement {
+		if strings.Contains(me.Message, "read-only") {
+			return &Error{Kind: ErrReadOnly, Err: err}
+		}
+
+		return err
+	}
+
+	if kind, ok := errorKinds[me.Number]; ok {
+		return &Error{Kind: kind, Err: err}
+	}
+
+	return err
+}
@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestClassifyError(t *testing.T) {
+	err := ClassifyError(&mysql.MySQLError{Number: ErDupEntry, Message: "Duplicate entry 'tdj' for key 'demo.uk_name'"})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Fatal("expect a duplicate key error", err)
+	}
+
+	var de *DuplicateKeyError
+	if !errors.As(err, &de) || de.Key != "uk_name" || de.Entry != "tdj" {
+		t.Error("unexpected duplicate key", de)
+	}
+
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) || me.Number != ErDupEntry {
+		t.Error("the driver error should still be reachable")
+	}
+
+	kinds := map[uint16]error{
+		ErNoReferencedRow2: ErrForeignKey,
+		ErLockDeadlock:     ErrDeadlock,
+		ErLockWaitTimeout:  ErrLockTimeout,
+		ErDataTooLong:      ErrDataTooLong,
+		ErReadOnlyMode:     ErrReadOnly,
+	}
+	for number, kind := range kinds {
+		err = ClassifyError(&mysql.MySQLError{Number: number})
+		if !errors.Is(err, kind) {
+			t.Error("unexpected kind", number, err)
+		}
+		if errors.Is(err, ErrDuplicateKey) {
+			t.Error("only 1062 is a duplicate key", number)
+		}
+	}
+
+	err = ClassifyError(&mysql.MySQLError{Number: ErOptionPreventsStatement, Message: "The MySQL server is running with the --super-read-only option so it cannot execute this statement"})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Error("--super-read-only should be read only", err)
+	}
+	err = ClassifyError(&mysql.MySQLError{Number: ErOptionPreventsStatement, Message: "The MySQL server is running with the --secure-file-priv option so it cannot execute this statement"})
+	if errors.Is(err, ErrReadOnly) {
+		t.Error("--secure-file-priv is not read only", err)
+	}
+
+	if !errors.Is(ClassifyError(driver.ErrBadConn), ErrConnectionLost) {
+		t.Error("a bad connection should be a lost connection")
+	}
+
+	plain := errors.New("plain")
+	if ClassifyError(plain) != plain || ClassifyError(nil) != nil {
+		t.Error("unclassified errors should be returned unchanged")
+	}
+}
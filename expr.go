@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"context"
+)
+
+// RawExpr is a piece of sql written verbatim in place of a placeholder, with the args of its own placeholders.
+// It is accepted as the value of a pair, a condition or an inserted column, and in the list of SelectExpr,
+// under CondIn and CondNotIn it is put between parentheses, e.g. Expr("select id from vips").
+type RawExpr struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Expr returns a RawExpr, e.g. Expr("json_set(attrs, '$.color', ?)", "red").
+func Expr(sql string, args ...interface{}) RawExpr {
+	return RawExpr{SQL: sql, Args: args}
+}
+
+// Incr returns columnName + n, e.g. NewPair("stock", Incr("stock", 1)).
+func Incr(columnName string, n interface{}) RawExpr {
+	return Expr(columnName+" + ?", n)
+}
+
+// Decr returns columnName - n.
+func Decr(columnName string, n interface{}) RawExpr {
+	return Expr(columnName+" - ?", n)
+}
+
+func Now() RawExpr {
+	return Expr("now()")
+}
+
+// SelectExpr starts a select of exprs, joined by commas, from tableName.
+func (qb *QueryBuilder) SelectExpr(tableName string, exprs ...RawExpr) *QueryBuilder {
+	qb.start("select ")
+	for i, expr := range exprs {
+		if i > 0 {
+			qb.query += ", "
+		}
+		qb.buildValue(expr)
+	}
+	qb.query += " from " + tableName
+
+	return qb
+}
+
+// IncrementById adds delta to colName of a row, atomically, a negative delta decrements it.
+func (d *Dao) IncrementById(tableName string, id int64, colName string, delta int64) *ExecResult {
+	return d.IncrementByIdCtx(context.Background(), tableName, id, colName, delta)
+}
+
+func (d *Dao) IncrementByIdCtx(ctx context.Context, tableName string, id int64, colName string, delta int64) *ExecResult {
+	return d.UpdateByIdCtx(ctx, tableName, id, NewPair(colName, Incr(colName, delta)))
+}
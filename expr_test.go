@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExprSet(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Update("products").
+		Set(
+			NewPair("stock", Decr("stock", 2)),
+			NewPair("sold", Incr("sold", 2)),
+			NewPair("updated_at", Now()),
+			NewPair("attrs", Expr("json_set(attrs, '$.color', ?)", "red")),
+		).
+		WhereAnd(NewCondition("id", CondEqual, 7), NewCondition("stock", CondGreaterEqual, Expr("? + reserved", 2)))
+
+	expect := "update products set stock = stock - ?, sold = sold + ?, updated_at = now(), attrs = json_set(attrs, '$.color', ?)  " +
+		"where id = ?  and stock >= ? + reserved "
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{2, 2, "red", 7, 2}) {
+		t.Error(qb.Args())
+	}
+}
+
+func TestExprValuesAndSelect(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Insert("logs", "id", "msg", "add_time").
+		Values([]interface{}{1, "a", Now()}, []interface{}{2, Expr("concat(?, ?)", "b", "c"), Now()})
+
+	if qb.Query() != "insert into logs (id, msg, add_time) values (?, ?, now()), (?, concat(?, ?), now())" {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{1, "a", 2, "b", "c"}) {
+		t.Error(qb.Args())
+	}
+
+	qb.SelectExpr(TABLE_NAME, Expr("id"), Expr("age + ? as next_age", 1), Expr("if(age > ?, 'adult', 'child') as kind", 18)).
+		WhereAnd(NewCondition("name", CondLike, "a%"))
+
+	if qb.Query() != "select id, age + ? as next_age, if(age > ?, 'adult', 'child') as kind from people where name like ?" {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{1, 18, "a%"}) {
+		t.Error(qb.Args())
+	}
+}
+
+func TestExprIn(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Select(TABLE_NAME, "*").
+		WhereAnd(
+			NewCondition("id", CondIn, Expr("select people_id from vips where level > ?", 2)),
+			NewCondition("age", CondNotIn, 7))
+
+	if qb.Query() != "select * from people where id in (select people_id from vips where level > ?) and age not in (?)" {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{2, 7}) {
+		t.Error(qb.Args())
+	}
+}
@@ -1,5 +1,9 @@
 package mysql
 
+import (
+	"context"
+)
+
 const GenIdSql = "UPDATE id_gen SET max_id = last_insert_id(max_id + 1) WHERE name = ?"
 
 type IdGenerator struct {
@@ -18,7 +22,11 @@ func (ig *IdGenerator) SetClient(client *Client) *IdGenerator {
 }
 
 func (ig *IdGenerator) GenerateId(name string) (int64, error) {
-	r, err := ig.client.Exec(GenIdSql, name)
+	return ig.GenerateIdCtx(context.Background(), name)
+}
+
+func (ig *IdGenerator) GenerateIdCtx(ctx context.Context, name string) (int64, error) {
+	r, err := ig.client.ExecContext(ctx, GenIdSql, name)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,124 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InterpolateForDisplay returns query with its placeholders replaced by args, escaped the way the driver
+// interpolates them, times are rendered in UTC. The placeholders inside quotes and comments are kept,
+// as well as the placeholders left when args is too short. The result is meant for logs and debugging.
+func InterpolateForDisplay(query string, args []interface{}) string {
+	return interpolateForDisplay(query, args, time.UTC)
+}
+
+func interpolateForDisplay(query string, args []interface{}, loc *time.Location) string {
+	if len(args) == 0 {
+		return query
+	}
+
+	buf := make([]byte, 0, len(query)+len(args)*8)
+	i := 0
+
+	for _, token := range lexSQL(query) {
+		if token.kind != tokPlaceholder || i >= len(args) {
+			buf = append(buf, token.text...)
+			continue
+		}
+
+		buf = appendDisplayValue(buf, args[i], loc)
+		i++
+	}
+
+	return string(buf)
+}
+
+func appendDisplayValue(buf []byte, arg interface{}, loc *time.Location) []byte {
+	if _, ok := arg.(driver.Valuer); ok || !isDisplayBasicType(arg) {
+		v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return appendQuotedString(buf, fmt.Sprint(arg))
+		}
+		arg = v
+	}
+
+	switch v := arg.(type) {
+	case nil:
+		return append(buf, "NULL"...)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case float32:
+		return strconv.AppendFloat(buf, float64(v), 'g', -1, 32)
+	case bool:
+		if v {
+			return append(buf, '1')
+		}
+		return append(buf, '0')
+	case time.Time:
+		if v.IsZero() {
+			return append(buf, "'0000-00-00'"...)
+		}
+		return append(append(buf, '\''), formatDisplayTime(v.In(loc))...)
+	case []byte:
+		if v == nil {
+			return append(buf, "NULL"...)
+		}
+		return appendQuotedString(append(buf, "_binary"...), string(v))
+	case string:
+		return appendQuotedString(buf, v)
+	}
+
+	return appendQuotedString(buf, fmt.Sprint(arg))
+}
+
+func isDisplayBasicType(arg interface{}) bool {
+	switch arg.(type) {
+	case nil, int64, int, uint64, float64, float32, bool, time.Time, []byte, string:
+		return true
+	}
+
+	return false
+}
+
+// formatDisplayTime formats t the way MySQL prints a datetime, microseconds without trailing zeros
+// included, followed by the closing quote.
+func formatDisplayTime(t time.Time) string {
+	s := t.Format("2006-01-02 15:04:05.000000")
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+
+	return s + "'"
+}
+
+// appendQuotedString quotes s escaping it with backslashes, as the driver does without NO_BACKSLASH_ESCAPES.
+func appendQuotedString(buf []byte, s string) []byte {
+	buf = append(buf, '\'')
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\x00':
+			buf = append(buf, '\\', '0')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\x1a':
+			buf = append(buf, '\\', 'Z')
+		case '\'', '"', '\\':
+			buf = append(buf, '\\', c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+
+	return append(buf, '\'')
+}
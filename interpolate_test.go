@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestInterpolateForDisplay(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 120000000, time.UTC)
+	name := "bob"
+
+	cases := []struct {
+		query  string
+		args   []interface{}
+		expect string
+	}{
+		{"select * from t where a = ? and b = ?", []interface{}{1, "x'y\\z"}, `select * from t where a = 1 and b = 'x\'y\\z'`},
+		{"select '?', `a?` from t where a like ? -- ?\n and b = ?", []interface{}{"50%", nil}, "select '?', `a?` from t where a like '50%' -- ?\n and b = NULL"},
+		{"insert into t values (?, ?, ?, ?)", []interface{}{[]byte("ab"), ts, true, 1.5}, "insert into t values (_binary'ab', '2024-01-02 03:04:05.12', 1, 1.5)"},
+		{"select ?, ?, ?", []interface{}{&name, sql.NullString{}, uint8(7)}, "select 'bob', NULL, 7"},
+		{"select ?, ?", []interface{}{time.Time{}}, "select '0000-00-00', ?"},
+	}
+
+	for _, c := range cases {
+		if s := InterpolateForDisplay(c.query, c.args); s != c.expect {
+			t.Error(s)
+		}
+	}
+}
+
+func TestQueryBuilderString(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Update(TABLE_NAME).
+		Set(NewPair("name", "a?b")).
+		WhereAnd(NewCondition("id", CondIn, []int64{1, 2}))
+
+	if s := qb.String(); s != "update people set name = 'a?b'  where id in (1, 2)" {
+		t.Error(s)
+	}
+}
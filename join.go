@@ -0,0 +1,73 @@
+package mysql
+
+// Col is a column reference written verbatim in place of a placeholder, e.g. the right side of a join condition:
+// NewCondition("p.id", CondEqual, Col("o.people_id")), or the value of a pair: NewPair("p.age", Col("o.age")).
+type Col string
+
+// Join adds an inner join of table, which may carry an alias, e.g. "orders o", on conds joined with and.
+func (qb *QueryBuilder) Join(table string, on ...CondExpr) *QueryBuilder {
+	return qb.buildJoin("join", table, on)
+}
+
+func (qb *QueryBuilder) LeftJoin(table string, on ...CondExpr) *QueryBuilder {
+	return qb.buildJoin("left join", table, on)
+}
+
+func (qb *QueryBuilder) RightJoin(table string, on ...CondExpr) *QueryBuilder {
+	return qb.buildJoin("right join", table, on)
+}
+
+func (qb *QueryBuilder) CrossJoin(table string) *QueryBuilder {
+	return qb.buildJoin("cross join", table, nil)
+}
+
+// Using adds the using clause of the previous join, called in place of join conditions.
+func (qb *QueryBuilder) Using(columnNames ...string) *QueryBuilder {
+	if len(columnNames) == 0 {
+		return qb
+	}
+
+	qb.query += " using ("
+	for i, columnName := range columnNames {
+		if i > 0 {
+			qb.query += ", "
+		}
+		qb.query += columnName
+	}
+	qb.query += ")"
+
+	return qb
+}
+
+// DeleteFrom starts a multi-table delete of the rows of targets, e.g. DeleteFrom("p", "people p").Join(...).Where(...).
+func (qb *QueryBuilder) DeleteFrom(targets, tableName string) *QueryBuilder {
+	qb.start("delete " + targets + " from " + tableName)
+	return qb
+}
+
+func (qb *QueryBuilder) buildJoin(kind, table string, on []CondExpr) *QueryBuilder {
+	qb.query += " " + kind + " " + table
+
+	return qb.buildCondClause(" on ", And(on...))
+}
+
+// buildValue writes the placeholder of value, value itself when it is a Col, the sql of a RawExpr
+// or the parenthesized query of a *QueryBuilder, followed by their args.
+func (qb *QueryBuilder) buildValue(value interface{}) {
+	switch v := value.(type) {
+	case Col:
+		qb.query += string(v)
+		return
+	case *QueryBuilder:
+		qb.query += "(" + v.query + ")"
+		qb.args = append(qb.args, v.args...)
+		return
+	case RawExpr:
+		qb.query += v.SQL
+		qb.args = append(qb.args, v.Args...)
+		return
+	}
+
+	qb.query += "?"
+	qb.args = append(qb.args, value)
+}
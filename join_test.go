@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectJoin(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Select("people p", "p.name, count(o.id)").
+		LeftJoin("orders o", NewCondition("o.people_id", CondEqual, Col("p.id")), NewCondition("o.status", CondEqual, 1)).
+		Join("cities c").Using("city_id").
+		CrossJoin("calendar d").
+		Where(NewCondition("p.age", CondGreater, 18)).
+		GroupBy("p.name")
+
+	expect := "select p.name, count(o.id) from people p left join orders o on o.people_id = p.id  and o.status = ?  " +
+		"join cities c using (city_id) cross join calendar d where p.age > ?  group by p.name"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{1, 18}) {
+		t.Error(qb.Args())
+	}
+}
+
+func TestUpdateDeleteJoin(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Update("people p").
+		Join("stats s", NewCondition("s.people_id", CondEqual, Col("p.id"))).
+		Set(NewPair("p.age", Col("s.age")), NewPair("p.name", "x")).
+		WhereAnd(NewCondition("s.day", CondEqual, "2024-01-01"))
+
+	expect := "update people p join stats s on s.people_id = p.id  set p.age = s.age, p.name = ?  where s.day = ? "
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{"x", "2024-01-01"}) {
+		t.Error(qb.Args())
+	}
+
+	qb.DeleteFrom("p", "people p").
+		LeftJoin("orders o", NewCondition("o.people_id", CondEqual, Col("p.id"))).
+		Where(NewCondition("o.status", CondEqual, 0))
+
+	expect = "delete p from people p left join orders o on o.people_id = p.id  where o.status = ? "
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+}
+
+func TestJoinColIn(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Select("people p", "*").
+		Join("orders o", NewCondition("o.people_id", CondEqual, Col("p.id"))).
+		Where(NewCondition("o.status", CondIn, Col("p.status")), NewCondition("o.id", CondNotIn, Col("p.last_order_id")))
+
+	expect := "select * from people p join orders o on o.people_id = p.id  where o.status in (p.status) and o.id not in (p.last_order_id)"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if len(qb.Args()) != 0 {
+		t.Error(qb.Args())
+	}
+}
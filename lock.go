@@ -0,0 +1,68 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ForUpdate adds a locking read clause, to be followed by Of, NoWait or SkipLocked if needed.
+func (qb *QueryBuilder) ForUpdate() *QueryBuilder {
+	qb.query += " for update"
+	return qb
+}
+
+// ForShare adds a shared locking read clause, MySQL 8.0 and later.
+func (qb *QueryBuilder) ForShare() *QueryBuilder {
+	qb.query += " for share"
+	return qb
+}
+
+// LockInShareMode is the shared locking read clause understood by MySQL 5.7, it takes no modifier.
+func (qb *QueryBuilder) LockInShareMode() *QueryBuilder {
+	qb.query += " lock in share mode"
+	return qb
+}
+
+// Of restricts the previous ForUpdate or ForShare to tables, named by their aliases if any.
+func (qb *QueryBuilder) Of(tables ...string) *QueryBuilder {
+	if len(tables) == 0 {
+		return qb
+	}
+
+	qb.query += " of "
+	for i, table := range tables {
+		if i > 0 {
+			qb.query += ", "
+		}
+		qb.query += table
+	}
+
+	return qb
+}
+
+// NoWait makes the locking read fail at once instead of waiting for a locked row.
+func (qb *QueryBuilder) NoWait() *QueryBuilder {
+	qb.query += " nowait"
+	return qb
+}
+
+// SkipLocked makes the locking read skip the locked rows.
+func (qb *QueryBuilder) SkipLocked() *QueryBuilder {
+	qb.query += " skip locked"
+	return qb
+}
+
+// SelectByIdForUpdate reads and locks a row, it always reads from the primary and
+// only makes sense inside a transaction.
+func (d *Dao) SelectByIdForUpdate(tableName, what string, id int64) *sql.Row {
+	return d.SelectByIdForUpdateCtx(context.Background(), tableName, what, id)
+}
+
+func (d *Dao) SelectByIdForUpdateCtx(ctx context.Context, tableName, what string, id int64) *sql.Row {
+	qb := new(QueryBuilder)
+	qb.Select(tableName, what).
+		WhereAnd(NewCondition("id", CondEqual, id)).
+		ForUpdate()
+
+	return d.QueryRowContext(ctx, qb.Query(), qb.Args()...)
+}
@@ -0,0 +1,30 @@
+package mysql
+
+import (
+	"testing"
+)
+
+func TestLockingRead(t *testing.T) {
+	cases := map[*QueryBuilder]string{
+		new(QueryBuilder).Select(TABLE_NAME, "*").WhereAnd(NewCondition("id", CondEqual, 1)).ForUpdate(): "select * from people where id = ?  for update",
+		new(QueryBuilder).Select("people p", "*").Join("orders o", NewCondition("o.people_id", CondEqual, Col("p.id"))).
+			ForUpdate().Of("p", "o").SkipLocked(): "select * from people p join orders o on o.people_id = p.id  for update of p, o skip locked",
+		new(QueryBuilder).Select(TABLE_NAME, "*").Limit(0, 1).ForShare().NoWait(): "select * from people limit ?, ? for share nowait",
+		new(QueryBuilder).Select(TABLE_NAME, "*").LockInShareMode():               "select * from people lock in share mode",
+	}
+
+	for qb, expect := range cases {
+		if qb.Query() != expect {
+			t.Error(qb.Query())
+		}
+	}
+}
+
+func TestGetByIdForUpdateOutsideTransaction(t *testing.T) {
+	so := NewSimpleOrm([]byte("-"), nil, false)
+
+	find, err := so.GetByIdForUpdate("demo", 1, new(demoEntity))
+	if find || err != ErrNotInTransaction {
+		t.Error(find, err)
+	}
+}
@@ -0,0 +1,194 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	MetricQueriesTotal        = "mysql_queries_total"
+	MetricQueryDuration       = "mysql_query_duration_seconds"
+	MetricErrorsTotal         = "mysql_errors_total"
+	MetricTransactionsTotal   = "mysql_transactions_total"
+	MetricPoolGetsTotal       = "mysql_pool_gets_total"
+	MetricPoolPutsTotal       = "mysql_pool_puts_total"
+	MetricPoolWaitDuration    = "mysql_pool_wait_duration_seconds"
+	MetricPoolIdleClients     = "mysql_pool_idle_clients"
+	MetricPoolInUseClients    = "mysql_pool_in_use_clients"
+	MetricDBOpenConnections   = "mysql_db_open_connections"
+	MetricDBInUseConnections  = "mysql_db_in_use_connections"
+	MetricDBIdleConnections   = "mysql_db_idle_connections"
+	MetricDBWaitTotal         = "mysql_db_wait_total"
+	MetricDBWaitDurationTotal = "mysql_db_wait_duration_seconds_total"
+	MetricDBMaxIdleClosed     = "mysql_db_max_idle_closed_total"
+	MetricDBMaxLifetimeClosed = "mysql_db_max_lifetime_closed_total"
+
+	TxStarted    = "started"
+	TxCommitted  = "committed"
+	TxRolledBack = "rolled_back"
+)
+
+var metricHelps = map[string]string{
+	MetricQueriesTotal:        "Statements executed by operation and table.",
+	MetricQueryDuration:       "Statement latency by operation and table.",
+	MetricErrorsTotal:         "Statement errors by MySQL error number, 0 for the errors not returned by the server.",
+	MetricTransactionsTotal:   "Outermost transactions by event: started, committed or rolled_back.",
+	MetricPoolGetsTotal:       "Clients got from the pool.",
+	MetricPoolPutsTotal:       "Clients put back into the pool.",
+	MetricPoolWaitDuration:    "Time spent getting a client from the pool.",
+	MetricPoolIdleClients:     "Clients idle in the pool.",
+	MetricPoolInUseClients:    "Clients got from the pool and not put back yet.",
+	MetricDBOpenConnections:   "Open connections of the sql.DB, see sql.DBStats.",
+	MetricDBInUseConnections:  "Connections in use of the sql.DB.",
+	MetricDBIdleConnections:   "Idle connections of the sql.DB.",
+	MetricDBWaitTotal:         "Connections waited for by the sql.DB.",
+	MetricDBWaitDurationTotal: "Time the sql.DB waited for connections.",
+	MetricDBMaxIdleClosed:     "Connections closed by the sql.DB because of SetMaxIdleConns.",
+	MetricDBMaxLifetimeClosed: "Connections closed by the sql.DB because of SetConnMaxLifetime.",
+}
+
+type Labels map[string]string
+
+// MetricsCollector receives the metrics of the clients and pools, the Metric* constants are the names used.
+// Durations are observed in seconds.
+type MetricsCollector interface {
+	AddCounter(name string, labels Labels, delta float64)
+	SetGauge(name string, labels Labels, value float64)
+	ObserveHistogram(name string, labels Labels, value float64)
+}
+
+// MetricsSource sets gauges read at collection time, e.g. the pool sizes.
+type MetricsSource interface {
+	CollectMetrics(collector MetricsCollector)
+}
+
+type MetricsSourceFunc func(collector MetricsCollector)
+
+func (f MetricsSourceFunc) CollectMetrics(collector MetricsCollector) {
+	f(collector)
+}
+
+// MetricsMiddleware counts and times the statements going through a client, counts their errors
+// and the outermost transactions.
+func MetricsMiddleware(collector MetricsCollector) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			err := next(ctx, op)
+			if op.Start.IsZero() {
+				// stopped by a later middleware, nothing was executed
+				return err
+			}
+
+			labels := Labels{"op": statementOp(op), "table": statementTable(op.Query)}
+			collector.AddCounter(MetricQueriesTotal, labels, 1)
+			collector.ObserveHistogram(MetricQueryDuration, labels, op.Duration.Seconds())
+
+			if op.Err != nil {
+				collector.AddCounter(MetricErrorsTotal, Labels{"number": errorNumber(op.Err)}, 1)
+			}
+
+			if !op.IsSavepoint() {
+				switch {
+				case op.Kind == OpBegin && op.Err == nil:
+					collector.AddCounter(MetricTransactionsTotal, Labels{"event": TxStarted}, 1)
+				case op.Kind == OpCommit && op.Err == nil:
+					collector.AddCounter(MetricTransactionsTotal, Labels{"event": TxCommitted}, 1)
+				case op.Kind == OpRollback:
+					collector.AddCounter(MetricTransactionsTotal, Labels{"event": TxRolledBack}, 1)
+				}
+			}
+
+			return err
+		}
+	}
+}
+
+// CollectDBStats sets the sql.DBStats gauges of the client, labels tell the client apart.
+func (c *Client) CollectDBStats(collector MetricsCollector, labels Labels) {
+	collectDBStats(collector, labels, c.db.Stats())
+}
+
+func collectDBStats(collector MetricsCollector, labels Labels, stats sql.DBStats) {
+	collector.SetGauge(MetricDBOpenConnections, labels, float64(stats.OpenConnections))
+	collector.SetGauge(MetricDBInUseConnections, labels, float64(stats.InUse))
+	collector.SetGauge(MetricDBIdleConnections, labels, float64(stats.Idle))
+	collector.SetGauge(MetricDBWaitTotal, labels, float64(stats.WaitCount))
+	collector.SetGauge(MetricDBWaitDurationTotal, labels, stats.WaitDuration.Seconds())
+	collector.SetGauge(MetricDBMaxIdleClosed, labels, float64(stats.MaxIdleClosed))
+	collector.SetGauge(MetricDBMaxLifetimeClosed, labels, float64(stats.MaxLifetimeClosed))
+}
+
+// statementOp returns the verb of a statement, e.g. select, or the Kind of a transaction operation.
+func statementOp(op *Operation) string {
+	switch op.Kind {
+	case OpExec, OpQuery, OpQueryRow:
+	default:
+		return op.Kind
+	}
+
+	for _, token := range lexSQL(op.Query) {
+		if token.kind == tokIdent {
+			return strings.ToLower(token.text)
+		}
+		if token.kind != tokSpace && token.kind != tokComment && token.kind != tokPunct {
+			break
+		}
+	}
+
+	return op.Kind
+}
+
+var tableModifiers = map[string]bool{"low_priority": true, "delayed": true, "high_priority": true, "ignore": true, "quick": true, "into": true}
+
+// statementTable returns the first table of a select, insert, replace, update or delete statement, "" when not found.
+func statementTable(query string) string {
+	var tokens []sqlToken
+	for _, token := range lexSQL(query) {
+		if token.kind != tokSpace && token.kind != tokComment {
+			tokens = append(tokens, token)
+		}
+	}
+
+	i := 0
+	for i < len(tokens) && tokens[i].kind == tokPunct && tokens[i].text == "(" {
+		i++
+	}
+	if i >= len(tokens) {
+		return ""
+	}
+
+	switch strings.ToLower(tokens[i].text) {
+	case "insert", "replace", "update":
+		i++
+		for i < len(tokens) && tokens[i].kind == tokIdent && tableModifiers[strings.ToLower(tokens[i].text)] {
+			i++
+		}
+	case "select", "delete":
+		for i < len(tokens) && !isKeyword(tokens[i], "from") {
+			i++
+		}
+		i++
+	default:
+		return ""
+	}
+
+	if i >= len(tokens) || (tokens[i].kind != tokIdent && tokens[i].kind != tokQuotedIdent) {
+		return ""
+	}
+
+	return strings.Replace(tokens[i].text, "`", "", -1)
+}
+
+func errorNumber(err error) string {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return strconv.Itoa(int(me.Number))
+	}
+
+	return "0"
+}
@@ -0,0 +1,82 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type recordCollector struct {
+	counters map[string]float64
+}
+
+func (r *recordCollector) AddCounter(name string, labels Labels, delta float64) {
+	r.counters[name+sortedLabelsKey(labels)] += delta
+}
+
+func (r *recordCollector) SetGauge(name string, labels Labels, value float64) {
+	r.counters[name+sortedLabelsKey(labels)] = value
+}
+
+func (r *recordCollector) ObserveHistogram(name string, labels Labels, value float64) {
+	r.counters[name+"_count"+sortedLabelsKey(labels)]++
+}
+
+func sortedLabelsKey(labels Labels) string {
+	key := ""
+	pairs := sortedLabels(labels)
+	for i := 0; i < len(pairs); i += 2 {
+		key += "," + pairs[i] + "=" + pairs[i+1]
+	}
+
+	return key
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	collector := &recordCollector{counters: make(map[string]float64)}
+	handler := chainMiddlewares(func(ctx context.Context, op *Operation) error {
+		op.Start = time.Now()
+		if op.Kind == OpExec {
+			op.Err = &mysql.MySQLError{Number: ErDupEntry, Message: "Duplicate entry"}
+		}
+		return op.Err
+	}, []Middleware{MetricsMiddleware(collector)})
+
+	handler(context.Background(), &Operation{Kind: OpQuery, Query: "select * from `people` where id = ?"})
+	handler(context.Background(), &Operation{Kind: OpExec, Query: "insert ignore into people (name) values (?)"})
+	handler(context.Background(), &Operation{Kind: OpBegin, Query: "BEGIN"})
+	handler(context.Background(), &Operation{Kind: OpCommit, Query: "COMMIT"})
+
+	for key, expect := range map[string]float64{
+		MetricQueriesTotal + ",op=select,table=people":        1,
+		MetricQueryDuration + "_count,op=select,table=people": 1,
+		MetricQueriesTotal + ",op=insert,table=people":        1,
+		MetricErrorsTotal + ",number=1062":                    1,
+		MetricQueriesTotal + ",op=begin,table=":               1,
+		MetricTransactionsTotal + ",event=started":            1,
+		MetricTransactionsTotal + ",event=committed":          1,
+	} {
+		if collector.counters[key] != expect {
+			t.Error(key, collector.counters[key])
+		}
+	}
+}
+
+func TestStatementTable(t *testing.T) {
+	for query, expect := range map[string]string{
+		"select a, b from demo.people p where id = 1":          "demo.people",
+		"(select * from `people`) union all (select * from t)": "people",
+		"insert low_priority into people values (1)":           "people",
+		"replace people set a = 1":                             "people",
+		"update ignore people set a = 1":                       "people",
+		"delete from people where id = 1":                      "people",
+		"select 1":                                             "",
+		"SET SESSION innodb_lock_wait_timeout = ?":             "",
+	} {
+		if table := statementTable(query); table != expect {
+			t.Error(query, table)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	OpExec     = "exec"
+	OpQuery    = "query"
+	OpQueryRow = "query_row"
+	OpBegin    = "begin"
+	OpCommit   = "commit"
+	OpRollback = "rollback"
+)
+
+// Operation is a statement going through the middleware chain of a Client. A middleware may change Query and Args
+// before calling next, once next returns Start, Duration, Err and the result field matching Kind are set.
+// Nested Begin, Commit and Rollback carry their SAVEPOINT statement as Query. A middleware not calling next for
+// the outermost Commit makes it roll the transaction back and fail.
+type Operation struct {
+	Kind  string
+	Query string
+	Args  []interface{}
+
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+
+	Result sql.Result
+	Rows   *sql.Rows
+	Row    *sql.Row
+
+	Client *Client
+
+	txOptions *sql.TxOptions
+}
+
+type Handler func(ctx context.Context, op *Operation) error
+
+type Middleware func(next Handler) Handler
+
+func chainMiddlewares(core Handler, middlewares []Middleware) Handler {
+	handler := core
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// IsSavepoint tells whether a Begin, Commit or Rollback operation is a nested one, executed with a SAVEPOINT statement.
+func (op *Operation) IsSavepoint() bool {
+	switch op.Kind {
+	case OpBegin, OpCommit, OpRollback:
+		return op.Client != nil && op.Client.txDepth > 0
+	}
+
+	return false
+}
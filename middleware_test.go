@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClient_Use(t *testing.T) {
+	client, err := newMysqlTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	errReadOnly := errors.New("writes are not allowed")
+
+	client.Use(
+		func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				kinds = append(kinds, op.Kind)
+				return next(ctx, op)
+			}
+		},
+		func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation) error {
+				if op.Kind == OpExec && strings.HasPrefix(op.Query, "delete") {
+					return errReadOnly
+				}
+				return next(ctx, op)
+			}
+		},
+	)
+
+	_, err = client.Exec("delete from people where id = ?", 1)
+	if err != errReadOnly {
+		t.Error("the delete should have been refused", err)
+	}
+	if len(kinds) != 1 || kinds[0] != OpExec {
+		t.Error("unexpected kinds", kinds)
+	}
+}
+
+func TestClient_MiddlewareTiming(t *testing.T) {
+	client, _ := newMysqlTestClient()
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			err := next(ctx, op)
+			fmt.Println(op.Kind, op.Query, op.Args, op.Duration, op.Err)
+			return err
+		}
+	})
+
+	client.WithTransaction(func(tx *Client) error {
+		_, err := tx.Exec("update people set age = ? where name = ?", 11, "a")
+		return err
+	})
+}
+
+func TestClient_MiddlewareStopsCommit(t *testing.T) {
+	client, _ := newMysqlTestClient()
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			if op.Kind == OpCommit {
+				return nil
+			}
+			return next(ctx, op)
+		}
+	})
+
+	err := client.Begin()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if client.Commit() == nil {
+		t.Error("a commit stopped by a middleware should fail")
+	}
+	if client.InTransaction() {
+		t.Error("the stopped transaction should be over")
+	}
+}
@@ -1,6 +1,10 @@
 package mysql
 
 import (
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/go-jar/pool"
 )
 
@@ -8,17 +12,31 @@ type PoolConfig struct {
 	pool.Config
 
 	NewClientFunc func() (*Client, error)
+
+	// Name labels the metrics of the pool.
+	Name string
+	// Metrics receives the gets, puts and wait times of the pool when not nil,
+	// register the pool as a MetricsSource for its idle count and the sql.DBStats of its clients.
+	Metrics MetricsCollector
 }
 
 type Pool struct {
 	pl *pool.Pool
 
 	config *PoolConfig
+
+	lock      sync.Mutex
+	clients   map[*Client]uint64
+	clientSeq uint64
+	// inUse holds the clients got and neither put back nor freed
+	inUse map[*Client]bool
 }
 
 func NewPool(config *PoolConfig) *Pool {
 	p := &Pool{
-		config: config,
+		config:  config,
+		clients: make(map[*Client]uint64),
+		inUse:   make(map[*Client]bool),
 	}
 
 	if config.NewConnFunc == nil {
@@ -31,18 +49,81 @@ func NewPool(config *PoolConfig) *Pool {
 }
 
 func (p *Pool) Get() (*Client, error) {
+	start := time.Now()
 	conn, err := p.pl.Get()
+	if p.config.Metrics != nil {
+		labels := Labels{"pool": p.config.Name}
+		p.config.Metrics.AddCounter(MetricPoolGetsTotal, labels, 1)
+		p.config.Metrics.ObserveHistogram(MetricPoolWaitDuration, labels, time.Since(start).Seconds())
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return conn.(*Client), nil
+	client := conn.(*Client)
+	p.lock.Lock()
+	p.inUse[client] = true
+	p.lock.Unlock()
+
+	return client, nil
 }
 
 func (p *Pool) Put(client *Client) error {
+	client.resetTx()
+
+	p.lock.Lock()
+	delete(p.inUse, client)
+	p.lock.Unlock()
+
+	if p.config.Metrics != nil {
+		p.config.Metrics.AddCounter(MetricPoolPutsTotal, Labels{"pool": p.config.Name}, 1)
+	}
+
 	return p.pl.Put(client)
 }
 
+// CollectMetrics sets the idle and in use gauges of the pool and the sql.DBStats of each of its clients.
+func (p *Pool) CollectMetrics(collector MetricsCollector) {
+	p.lock.Lock()
+	clients := make(map[*Client]uint64, len(p.clients))
+	for client, id := range p.clients {
+		clients[client] = id
+	}
+	inUse := len(p.inUse)
+	p.lock.Unlock()
+
+	idle := len(clients) - inUse
+	if idle < 0 {
+		// clients made by a custom NewConnFunc are not tracked
+		idle = 0
+	}
+
+	labels := Labels{"pool": p.config.Name}
+	collector.SetGauge(MetricPoolIdleClients, labels, float64(idle))
+	collector.SetGauge(MetricPoolInUseClients, labels, float64(inUse))
+
+	for client, id := range clients {
+		client.CollectDBStats(collector, Labels{"pool": p.config.Name, "client": strconv.FormatUint(id, 10)})
+	}
+}
+
 func (p *Pool) newConn() (pool.IConn, error) {
-	return p.config.NewClientFunc()
+	client, err := p.config.NewClientFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	p.clientSeq++
+	p.clients[client] = p.clientSeq
+	p.lock.Unlock()
+
+	client.onFree = func() {
+		p.lock.Lock()
+		delete(p.clients, client)
+		delete(p.inUse, client)
+		p.lock.Unlock()
+	}
+
+	return client, nil
 }
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"fmt"
+	"os"
 	"testing"
 	"time"
 )
@@ -32,3 +33,45 @@ func testPool(p *Pool, t *testing.T) {
 
 	p.Put(client)
 }
+
+func TestPool_Metrics(t *testing.T) {
+	collector := NewPrometheusCollector()
+
+	config := &PoolConfig{NewClientFunc: newMysqlTestClient, Name: "demo", Metrics: collector}
+	config.MaxConns = 10
+	config.MaxIdleTime = time.Second * 2
+
+	pool := NewPool(config)
+	collector.Register(pool)
+
+	client, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(client)
+
+	collector.WriteTo(os.Stdout)
+}
+
+func TestPool_InUse(t *testing.T) {
+	config := &PoolConfig{NewClientFunc: newMysqlTestClient, Name: "demo"}
+	config.MaxConns = 10
+	config.MaxIdleTime = time.Second * 2
+
+	pool := NewPool(config)
+	c1, _ := pool.Get()
+	c2, _ := pool.Get()
+
+	collector := &recordCollector{counters: make(map[string]float64)}
+	pool.CollectMetrics(collector)
+	if collector.counters[MetricPoolInUseClients+",pool=demo"] != 2 {
+		t.Error("unexpected in use clients", collector.counters)
+	}
+
+	pool.Put(c1)
+	c2.Free()
+	pool.CollectMetrics(collector)
+	if collector.counters[MetricPoolInUseClients+",pool=demo"] != 0 {
+		t.Error("a freed client should not be in use", collector.counters)
+	}
+}
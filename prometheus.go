@@ -0,0 +1,269 @@
+package mysql
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	metricCounter   = "counter"
+	metricGauge     = "gauge"
+	metricHistogram = "histogram"
+)
+
+// DefaultMetricBuckets are the histogram buckets in seconds, from 1ms to 10s.
+var DefaultMetricBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusCollector is a MetricsCollector keeping the metrics in memory and writing them
+// in the Prometheus text exposition format, it is also an http.Handler serving them.
+type PrometheusCollector struct {
+	lock     sync.Mutex
+	buckets  []float64
+	families map[string]*metricFamily
+	sources  []MetricsSource
+}
+
+type metricFamily struct {
+	kind   string
+	series map[string]*metricSeries
+}
+
+type metricSeries struct {
+	labels []string
+
+	value float64
+
+	bucketCounts []uint64
+	sum          float64
+	count        uint64
+}
+
+func NewPrometheusCollector() *PrometheusCollector {
+	return &PrometheusCollector{
+		buckets:  DefaultMetricBuckets,
+		families: make(map[string]*metricFamily),
+	}
+}
+
+// SetBuckets replaces the histogram buckets, it must be called before any observation.
+func (p *PrometheusCollector) SetBuckets(buckets ...float64) *PrometheusCollector {
+	p.buckets = append([]float64(nil), buckets...)
+	sort.Float64s(p.buckets)
+
+	return p
+}
+
+// Register adds sources collected each time the metrics are written, their gauges are not kept between two writes.
+func (p *PrometheusCollector) Register(sources ...MetricsSource) *PrometheusCollector {
+	p.lock.Lock()
+	p.sources = append(p.sources, sources...)
+	p.lock.Unlock()
+
+	return p
+}
+
+func (p *PrometheusCollector) AddCounter(name string, labels Labels, delta float64) {
+	p.lock.Lock()
+	p.getSeries(name, metricCounter, labels).value += delta
+	p.lock.Unlock()
+}
+
+func (p *PrometheusCollector) SetGauge(name string, labels Labels, value float64) {
+	p.lock.Lock()
+	p.getSeries(name, metricGauge, labels).value = value
+	p.lock.Unlock()
+}
+
+func (p *PrometheusCollector) ObserveHistogram(name string, labels Labels, value float64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	s := p.getSeries(name, metricHistogram, labels)
+	if s.bucketCounts == nil {
+		s.bucketCounts = make([]uint64, len(p.buckets))
+	}
+
+	for i, bound := range p.buckets {
+		if value <= bound {
+			s.bucketCounts[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (p *PrometheusCollector) getSeries(name, kind string, labels Labels) *metricSeries {
+	family, ok := p.families[name]
+	if !ok {
+		family = &metricFamily{kind: kind, series: make(map[string]*metricSeries)}
+		p.families[name] = family
+	}
+
+	pairs := sortedLabels(labels)
+	key := strings.Join(pairs, "\xff")
+
+	s, ok := family.series[key]
+	if !ok {
+		s = &metricSeries{labels: pairs}
+		family.series[key] = s
+	}
+
+	return s
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format, sorted by name and labels.
+func (p *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	p.lock.Lock()
+	sources := p.sources
+	p.lock.Unlock()
+
+	collected := &PrometheusCollector{families: make(map[string]*metricFamily)}
+	for _, source := range sources {
+		source.CollectMetrics(collected)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	families := make(map[string]*metricFamily, len(p.families)+len(collected.families))
+	for name, family := range collected.families {
+		families[name] = family
+	}
+	for name, family := range p.families {
+		if cf, ok := families[name]; ok {
+			for key, s := range family.series {
+				cf.series[key] = s
+			}
+		} else {
+			families[name] = family
+		}
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, name := range names {
+		p.writeFamily(bw, name, families[name])
+	}
+	err := bw.Flush()
+
+	return cw.n, err
+}
+
+func (p *PrometheusCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+func (p *PrometheusCollector) writeFamily(w *bufio.Writer, name string, family *metricFamily) {
+	kind := family.kind
+	if kind == metricGauge && strings.HasSuffix(name, "_total") {
+		// a cumulative value read from its source, e.g. sql.DBStats.WaitCount
+		kind = metricCounter
+	}
+
+	if help, ok := metricHelps[name]; ok {
+		w.WriteString("# HELP " + name + " " + help + "\n")
+	}
+	w.WriteString("# TYPE " + name + " " + kind + "\n")
+
+	keys := make([]string, 0, len(family.series))
+	for key := range family.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := family.series[key]
+		if family.kind != metricHistogram {
+			writeSample(w, name, s.labels, s.value)
+			continue
+		}
+
+		for i, bound := range p.buckets {
+			var count uint64
+			if i < len(s.bucketCounts) {
+				count = s.bucketCounts[i]
+			}
+			writeSample(w, name+"_bucket", append(s.labels, "le", formatMetricValue(bound)), float64(count))
+		}
+		writeSample(w, name+"_bucket", append(s.labels, "le", "+Inf"), float64(s.count))
+		writeSample(w, name+"_sum", s.labels, s.sum)
+		writeSample(w, name+"_count", s.labels, float64(s.count))
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels []string, value float64) {
+	w.WriteString(name)
+
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labels[i] + `="` + escapeLabelValue(labels[i+1]) + `"`)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteString(" " + formatMetricValue(value) + "\n")
+}
+
+// sortedLabels flattens labels into name, value pairs sorted by name.
+func sortedLabels(labels Labels) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names)*2)
+	for _, name := range names {
+		pairs = append(pairs, name, labels[name])
+	}
+
+	return pairs[:len(pairs):len(pairs)]
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func formatMetricValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	collector := NewPrometheusCollector().SetBuckets(0.1, 1)
+	collector.AddCounter(MetricQueriesTotal, Labels{"op": "select", "table": "people"}, 1)
+	collector.AddCounter(MetricQueriesTotal, Labels{"table": "people", "op": "select"}, 2)
+	collector.ObserveHistogram(MetricQueryDuration, Labels{"op": "select"}, 0.5)
+	collector.Register(MetricsSourceFunc(func(c MetricsCollector) {
+		c.SetGauge(MetricDBWaitTotal, Labels{"client": `a"b`}, 4)
+	}))
+
+	buf := new(bytes.Buffer)
+	n, err := collector.WriteTo(buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatal(n, err)
+	}
+
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE mysql_db_wait_total counter",
+		`mysql_db_wait_total{client="a\"b"} 4`,
+		"# TYPE mysql_queries_total counter",
+		`mysql_queries_total{op="select",table="people"} 3`,
+		"# TYPE mysql_query_duration_seconds histogram",
+		`mysql_query_duration_seconds_bucket{op="select",le="0.1"} 0`,
+		`mysql_query_duration_seconds_bucket{op="select",le="1"} 1`,
+		`mysql_query_duration_seconds_bucket{op="select",le="+Inf"} 1`,
+		`mysql_query_duration_seconds_sum{op="select"} 0.5`,
+		`mysql_query_duration_seconds_count{op="select"} 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Error("missing", line, "in", out)
+		}
+	}
+}
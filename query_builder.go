@@ -16,6 +16,8 @@ const (
 	CondNotIn        = "not in"
 	CondLike         = "like"
 	CondBetween      = "between"
+	CondExists       = "exists"
+	CondNotExists    = "not exists"
 )
 
 type QueryItem struct {
@@ -50,6 +52,14 @@ func NewPair(name string, value interface{}) *QueryItem {
 type QueryBuilder struct {
 	query string
 	args  []interface{}
+
+	// ctes are added by With and prefixed to the statement started by the next verb
+	ctes      []string
+	cteArgs   []interface{}
+	recursive bool
+
+	// ordered is set when the query ends with an order by or a limit, a union then parenthesizes it
+	ordered bool
 }
 
 func (qb *QueryBuilder) Query() string {
@@ -60,9 +70,43 @@ func (qb *QueryBuilder) Args() []interface{} {
 	return qb.args
 }
 
-func (qb *QueryBuilder) Insert(tableName string, columnNames ...string) *QueryBuilder {
+// start begins a new statement with query, prefixed by the ctes added since the previous one.
+func (qb *QueryBuilder) start(query string) {
 	qb.args = nil
-	qb.query = "insert into " + tableName + " ("
+	qb.query = query
+	qb.ordered = false
+
+	if len(qb.ctes) == 0 {
+		return
+	}
+
+	with := "with "
+	if qb.recursive {
+		with = "with recursive "
+	}
+	for i, cte := range qb.ctes {
+		if i > 0 {
+			with += ", "
+		}
+		with += cte
+	}
+
+	qb.query = with + " " + query
+	qb.args = qb.cteArgs
+	qb.ctes, qb.cteArgs, qb.recursive = nil, nil, false
+}
+
+// String returns the query with its args interpolated, for logs and debugging only, see InterpolateForDisplay.
+func (qb *QueryBuilder) String() string {
+	return InterpolateForDisplay(qb.query, qb.args)
+}
+
+func (qb *QueryBuilder) Insert(tableName string, columnNames ...string) *QueryBuilder {
+	return qb.buildInsert("insert into ", tableName, columnNames)
+}
+
+func (qb *QueryBuilder) buildInsert(verb, tableName string, columnNames []string) *QueryBuilder {
+	qb.start(verb + tableName + " (")
 	qb.query += strings.Join(columnNames, ", ") + ") values "
 	return qb
 }
@@ -83,14 +127,12 @@ func (qb *QueryBuilder) Values(values ...[]interface{}) *QueryBuilder {
 }
 
 func (qb *QueryBuilder) Delete(tableName string) *QueryBuilder {
-	qb.args = nil
-	qb.query = "delete from " + tableName
+	qb.start("delete from " + tableName)
 	return qb
 }
 
 func (qb *QueryBuilder) Update(tableName string) *QueryBuilder {
-	qb.args = nil
-	qb.query = "update " + tableName
+	qb.start("update " + tableName)
 	return qb
 }
 
@@ -103,18 +145,19 @@ func (qb *QueryBuilder) Set(items ...*QueryItem) *QueryBuilder {
 	qb.query += " set "
 
 	for i := 0; i < n; i++ {
-		qb.query += items[i].Name + " = ?, "
-		qb.args = append(qb.args, items[i].Value)
+		qb.query += items[i].Name + " = "
+		qb.buildValue(items[i].Value)
+		qb.query += ", "
 	}
-	qb.query += items[n].Name + " = ? "
-	qb.args = append(qb.args, items[n].Value)
+	qb.query += items[n].Name + " = "
+	qb.buildValue(items[n].Value)
+	qb.query += " "
 
 	return qb
 }
 
 func (qb *QueryBuilder) Select(tableName, what string) *QueryBuilder {
-	qb.args = nil
-	qb.query = "select " + what + " from " + tableName
+	qb.start("select " + what + " from " + tableName)
 	return qb
 }
 
@@ -141,6 +184,7 @@ func (qb *QueryBuilder) WhereOr(conditions ...*QueryItem) *QueryBuilder {
 func (qb *QueryBuilder) OrderBy(orderBy string) *QueryBuilder {
 	if orderBy != "" {
 		qb.query += " order by " + orderBy
+		qb.ordered = true
 	}
 	return qb
 }
@@ -179,6 +223,7 @@ func (qb *QueryBuilder) Limit(offset, cnt int64) *QueryBuilder {
 
 	qb.query += " limit ?, ?"
 	qb.args = append(qb.args, offset, cnt)
+	qb.ordered = true
 
 	return qb
 }
@@ -192,12 +237,12 @@ func (qb *QueryBuilder) buildInsertRow(args []interface{}) {
 	qb.query += "("
 
 	for i := 0; i < colNum; i++ {
-		qb.query += "?, "
-		qb.args = append(qb.args, args[i])
+		qb.buildValue(args[i])
+		qb.query += ", "
 	}
 
-	qb.query += "?)"
-	qb.args = append(qb.args, args[colNum])
+	qb.buildValue(args[colNum])
+	qb.query += ")"
 }
 
 func (qb *QueryBuilder) buildCondition(andOr string, conditions ...*QueryItem) {
@@ -216,11 +261,12 @@ func (qb *QueryBuilder) buildCondition(andOr string, conditions ...*QueryItem) {
 func (qb *QueryBuilder) buildConditionWhere(condition *QueryItem) {
 	switch condition.Condition {
 	case CondEqual, CondNotEqual, CondLess, CondLessEqual, CondGreater, CondGreaterEqual:
-		qb.query += condition.Name + " " + condition.Condition + " ? "
-		qb.args = append(qb.args, condition.Value)
+		qb.query += condition.Name + " " + condition.Condition + " "
+		qb.buildValue(condition.Value)
+		qb.query += " "
 	case CondLike:
-		qb.query += condition.Name + " like ?"
-		qb.args = append(qb.args, condition.Value)
+		qb.query += condition.Name + " like "
+		qb.buildValue(condition.Value)
 	case CondBetween:
 		qb.query += condition.Name + " between ? and ?"
 		rev := reflect.ValueOf(condition.Value)
@@ -229,11 +275,33 @@ func (qb *QueryBuilder) buildConditionWhere(condition *QueryItem) {
 		qb.buildConditionInOrNot("in", condition)
 	case CondNotIn:
 		qb.buildConditionInOrNot("not in", condition)
+	case CondExists, CondNotExists:
+		qb.query += condition.Condition + " "
+		qb.buildValue(condition.Value)
 	}
 }
 
 func (qb *QueryBuilder) buildConditionInOrNot(inOrNot string, condition *QueryItem) {
+	switch v := condition.Value.(type) {
+	case *QueryBuilder:
+		qb.query += condition.Name + " " + inOrNot + " "
+		qb.buildValue(v)
+		return
+	case RawExpr, Col:
+		qb.query += condition.Name + " " + inOrNot + " ("
+		qb.buildValue(v)
+		qb.query += ")"
+		return
+	}
+
 	rev := reflect.ValueOf(condition.Value)
+	if rev.Kind() != reflect.Slice && rev.Kind() != reflect.Array {
+		qb.query += condition.Name + " " + inOrNot + " ("
+		qb.buildValue(condition.Value)
+		qb.query += ")"
+		return
+	}
+
 	n := rev.Len() - 1
 	if n == -1 {
 		return
@@ -0,0 +1,269 @@
+package mysql
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const RedactedValue = "******"
+
+// Redactor masks the args of sensitive columns and the args matching a value pattern in the logged statements.
+// The column of an arg is found from the statement: the column compared to or assigned the placeholder,
+// or the column at the same position in the column list of an insert, whose rows are values or a select.
+type Redactor struct {
+	columns        map[string]bool
+	columnPatterns []*regexp.Regexp
+	valuePatterns  []*regexp.Regexp
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{
+		columns: make(map[string]bool),
+	}
+}
+
+func (r *Redactor) AddColumns(columns ...string) *Redactor {
+	for _, column := range columns {
+		r.columns[strings.ToLower(column)] = true
+	}
+
+	return r
+}
+
+// AddColumnPatterns masks the columns whose name matches one of patterns, e.g. (?i)token$.
+func (r *Redactor) AddColumnPatterns(patterns ...*regexp.Regexp) *Redactor {
+	r.columnPatterns = append(r.columnPatterns, patterns...)
+	return r
+}
+
+// AddValuePatterns masks the string args matching one of patterns whatever their column, e.g. a card number.
+func (r *Redactor) AddValuePatterns(patterns ...*regexp.Regexp) *Redactor {
+	r.valuePatterns = append(r.valuePatterns, patterns...)
+	return r
+}
+
+// AddEntity masks the columns of entity tagged as sensitive, e.g. `mysql:"password,sensitive"`.
+func (r *Redactor) AddEntity(entity interface{}) *Redactor {
+	return r.AddColumns(ReflectSensitiveColNames(reflect.TypeOf(entity))...)
+}
+
+// RedactArgs returns a copy of args in which the sensitive values are replaced by RedactedValue.
+func (r *Redactor) RedactArgs(query string, args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return args
+	}
+
+	redacted := make([]interface{}, len(args))
+	columns := placeholderColumns(query, len(args))
+
+	for i, arg := range args {
+		if r.isSensitiveColumn(columns[i]) || r.isSensitiveValue(arg) {
+			redacted[i] = RedactedValue
+		} else {
+			redacted[i] = arg
+		}
+	}
+
+	return redacted
+}
+
+func (r *Redactor) isSensitiveColumn(column string) bool {
+	if column == "" {
+		return false
+	}
+	if r.columns[column] {
+		return true
+	}
+
+	for _, pattern := range r.columnPatterns {
+		if pattern.MatchString(column) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Redactor) isSensitiveValue(arg interface{}) bool {
+	var s string
+	switch v := arg.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return false
+	}
+
+	for _, pattern := range r.valuePatterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// placeholderColumns returns the lower-cased, unqualified column of each of the n placeholders of query, "" when unknown.
+func placeholderColumns(query string, n int) []string {
+	var tokens []sqlToken
+	for _, token := range lexSQL(query) {
+		if token.kind != tokSpace && token.kind != tokComment {
+			tokens = append(tokens, token)
+		}
+	}
+
+	columns := make([]string, 0, n)
+	insertColumns, rowsStart, fromSelect := insertColumnList(tokens)
+	// the commas between the columns of a row are inside the parentheses of values, at the top of a select list
+	rowDepth := 1
+	if fromSelect {
+		rowDepth = 0
+	}
+	depth, position := 0, 0
+
+	for i, token := range tokens {
+		switch {
+		case token.kind == tokPunct && token.text == "(":
+			depth++
+			if depth == 1 && !fromSelect {
+				position = 0
+			}
+		case token.kind == tokPunct && token.text == ")":
+			depth--
+		case token.kind == tokPunct && token.text == "," && depth == rowDepth:
+			position++
+		case isKeyword(token, "update"):
+			// on duplicate key update: back to assignments
+			rowsStart = -1
+		case fromSelect && depth == 0 && token.kind == tokIdent && selectListEnds[strings.ToLower(token.text)]:
+			// the end of the select list, e.g. from or where
+			rowsStart = -1
+		case token.kind == tokPlaceholder:
+			column := ""
+			if rowsStart >= 0 && i > rowsStart && depth >= rowDepth {
+				// the position in the row, a placeholder nested in a function call belongs to the column of the call
+				if position < len(insertColumns) {
+					column = insertColumns[position]
+				}
+			} else {
+				column = placeholderColumn(tokens, i)
+			}
+			columns = append(columns, column)
+		}
+	}
+
+	for len(columns) < n {
+		columns = append(columns, "")
+	}
+
+	return columns
+}
+
+var selectListEnds = map[string]bool{"from": true, "where": true, "group": true, "having": true, "order": true, "limit": true, "union": true}
+
+// insertColumnList returns the column list of an insert or replace and the index of its values or select keyword,
+// telling whether the rows come from a select, -1 when query is not an insert.
+func insertColumnList(tokens []sqlToken) ([]string, int, bool) {
+	if len(tokens) == 0 || !(isKeyword(tokens[0], "insert") || isKeyword(tokens[0], "replace")) {
+		return nil, -1, false
+	}
+
+	var columns []string
+	inList := false
+
+	for i, token := range tokens {
+		switch {
+		case isKeyword(token, "values") || isKeyword(token, "value"):
+			return columns, i, false
+		case isKeyword(token, "select"):
+			return columns, i, true
+		case token.kind == tokPunct && token.text == "(":
+			inList = true
+		case token.kind == tokPunct && token.text == ")":
+			inList = false
+		case inList && (token.kind == tokIdent || token.kind == tokQuotedIdent):
+			columns = append(columns, columnName(token))
+		}
+	}
+
+	return nil, -1, false
+}
+
+var placeholderSkipKeywords = map[string]bool{
+	"and": true, "not": true, "in": true, "like": true, "between": true, "regexp": true, "is": true, "binary": true,
+}
+
+// placeholderColumn walks back from the placeholder at tokens[i] to the column it is compared to or assigned.
+// The name of a function called with the placeholder as argument is skipped, e.g. password = sha2(?, 256),
+// as well as the other arguments, the functions applied to the column, e.g. lower(email) = ?, and the modifiers,
+// e.g. password = binary ? or name collate utf8mb4_bin = ?.
+func placeholderColumn(tokens []sqlToken, i int) string {
+	for j := i - 1; j >= 0; j-- {
+		token := tokens[j]
+
+		switch token.kind {
+		case tokPlaceholder, tokOperator, tokString, tokNumber:
+			continue
+		case tokPunct:
+			switch token.text {
+			case "(":
+				if j > 0 && isFunctionName(tokens[j-1]) {
+					j--
+				}
+				continue
+			case ",", ")":
+				continue
+			}
+			return ""
+		case tokIdent:
+			if placeholderSkipKeywords[strings.ToLower(token.text)] {
+				continue
+			}
+			if isReservedWord(token.text) {
+				return ""
+			}
+			if j > 0 && isKeyword(tokens[j-1], "collate") {
+				j--
+				continue
+			}
+			return columnName(token)
+		case tokQuotedIdent:
+			return columnName(token)
+		default:
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// isFunctionName tells whether token, followed by an opening parenthesis, is the name of a called function.
+func isFunctionName(token sqlToken) bool {
+	return token.kind == tokIdent && !placeholderSkipKeywords[strings.ToLower(token.text)] && !isReservedWord(token.text)
+}
+
+var reservedWords = map[string]bool{
+	"select": true, "from": true, "where": true, "set": true, "values": true, "value": true, "limit": true,
+	"offset": true, "having": true, "by": true, "on": true, "or": true, "when": true, "then": true, "else": true,
+	"case": true, "update": true, "into": true, "as": true, "join": true, "using": true,
+}
+
+func isReservedWord(word string) bool {
+	return reservedWords[strings.ToLower(word)]
+}
+
+func isKeyword(token sqlToken, keyword string) bool {
+	return token.kind == tokIdent && strings.EqualFold(token.text, keyword)
+}
+
+// columnName returns the lower-cased column of an identifier, without its table and quotes.
+func columnName(token sqlToken) string {
+	name := token.text
+	if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+		name = name[dot+1:]
+	}
+
+	return strings.ToLower(strings.Trim(name, "`"))
+}
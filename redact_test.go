@@ -0,0 +1,114 @@
+package mysql
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+type redactEntity struct {
+	Id       int64  `mysql:"id"`
+	Name     string `mysql:"name"`
+	Password string `mysql:"password,sensitive"`
+}
+
+func newTestRedactor() *Redactor {
+	return NewRedactor().
+		AddEntity(&redactEntity{}).
+		AddColumns("phone").
+		AddColumnPatterns(regexp.MustCompile(`(?i)token$`)).
+		AddValuePatterns(regexp.MustCompile(`^\d{4}-\d{4}-\d{4}-\d{4}$`))
+}
+
+func TestRedactInsert(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Insert("user", "name", "password", "`phone`").
+		Values(
+			[]interface{}{"a", "secret-a", "111"},
+			[]interface{}{"b", "secret-b", "222"})
+
+	args := newTestRedactor().RedactArgs(qb.Query(), qb.Args())
+	expect := []interface{}{"a", RedactedValue, RedactedValue, "b", RedactedValue, RedactedValue}
+	if !reflect.DeepEqual(args, expect) {
+		t.Error(args)
+	}
+}
+
+func TestRedactUpdate(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Update("user").
+		Set(NewPair("name", "a"), NewPair("password", "secret"), NewPair("api_token", "t")).
+		WhereAnd(
+			NewCondition("u.phone", CondIn, []string{"111", "222"}),
+			NewCondition("name", CondEqual, "1234-5678-9012-3456"),
+			NewCondition("id", CondGreater, 1))
+
+	args := newTestRedactor().RedactArgs(qb.Query(), qb.Args())
+	expect := []interface{}{"a", RedactedValue, RedactedValue, RedactedValue, RedactedValue, RedactedValue, 1}
+	if !reflect.DeepEqual(args, expect) {
+		t.Error(qb.Query(), args)
+	}
+}
+
+func TestRedactQuoted(t *testing.T) {
+	query := "SELECT * FROM user WHERE note = 'password = ?' AND password = ? -- phone = ?\nAND name BETWEEN ? AND ?"
+
+	args := newTestRedactor().RedactArgs(query, []interface{}{"secret", "a", "b"})
+	expect := []interface{}{RedactedValue, "a", "b"}
+	if !reflect.DeepEqual(args, expect) {
+		t.Error(args)
+	}
+}
+
+func TestRedactFunctionCall(t *testing.T) {
+	redactor := newTestRedactor()
+
+	args := redactor.RedactArgs("update user set name = ?, password = sha2(?, 256) where id = ?", []interface{}{"a", "hunter2", 1})
+	if !reflect.DeepEqual(args, []interface{}{"a", RedactedValue, 1}) {
+		t.Error(args)
+	}
+
+	args = redactor.RedactArgs("insert into user (name, password, phone) values (?, sha2(?, 256), ?), (upper(?), sha2(?, 256), ?)",
+		[]interface{}{"a", "hunter2", "111", "b", "hunter3", "222"})
+	if !reflect.DeepEqual(args, []interface{}{"a", RedactedValue, RedactedValue, "b", RedactedValue, RedactedValue}) {
+		t.Error(args)
+	}
+
+	qb := new(QueryBuilder)
+	qb.Update("user").
+		Set(NewPair("password", Expr("sha2(concat(?, salt), 256)", "hunter2")), NewPair("name", "a")).
+		WhereAnd(NewCondition("lower(phone)", CondEqual, "111"))
+
+	args = redactor.RedactArgs(qb.Query(), qb.Args())
+	if !reflect.DeepEqual(args, []interface{}{RedactedValue, "a", RedactedValue}) {
+		t.Error(qb.Query(), args)
+	}
+}
+
+func TestRedactInsertSelect(t *testing.T) {
+	args := newTestRedactor().RedactArgs("insert into user (name, password, phone) select ?, sha2(?, 256), ? from dual where not exists (select 1 from user where phone = ?)",
+		[]interface{}{"a", "hunter2", "111", "111"})
+	if !reflect.DeepEqual(args, []interface{}{"a", RedactedValue, RedactedValue, RedactedValue}) {
+		t.Error(args)
+	}
+
+	args = newTestRedactor().RedactArgs("insert into user (name, password) select ?, ? on duplicate key update name = ?",
+		[]interface{}{"a", "hunter2", "b"})
+	if !reflect.DeepEqual(args, []interface{}{"a", RedactedValue, "b"}) {
+		t.Error(args)
+	}
+}
+
+func TestRedactModifiers(t *testing.T) {
+	redactor := newTestRedactor()
+
+	args := redactor.RedactArgs("select * from user where name = ? and password = binary ?", []interface{}{"a", "hunter2"})
+	if !reflect.DeepEqual(args, []interface{}{"a", RedactedValue}) {
+		t.Error(args)
+	}
+
+	args = redactor.RedactArgs("select * from user where phone collate utf8mb4_bin = ? and name = ? collate utf8mb4_bin", []interface{}{"111", "a"})
+	if !reflect.DeepEqual(args, []interface{}{RedactedValue, "a"}) {
+		t.Error(args)
+	}
+}
@@ -1,14 +1,72 @@
 package mysql
 
 import (
+	"bytes"
 	"database/sql"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
 const (
 	FieldTag = "mysql"
+
+	// FieldTagSensitive marks a column whose values are masked in logs, e.g. `mysql:"password,sensitive"`.
+	FieldTagSensitive = "sensitive"
 )
 
+// LookupColName returns the column name of a field from its mysql tag, without the tag options.
+func LookupColName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup(FieldTag)
+	if !ok {
+		return "", false
+	}
+
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		return tag[:i], true
+	}
+
+	return tag, true
+}
+
+// ReflectSensitiveColNames returns the columns of ret tagged as sensitive, embedded structs included.
+func ReflectSensitiveColNames(ret reflect.Type) []string {
+	if ret.Kind() == reflect.Ptr {
+		ret = ret.Elem()
+	}
+
+	if ret.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var colNames []string
+
+	for i := 0; i < ret.NumField(); i++ {
+		retF := ret.Field(i)
+
+		if retF.Type.Kind() == reflect.Ptr || retF.Type.Kind() == reflect.Struct {
+			colNames = append(colNames, ReflectSensitiveColNames(retF.Type)...)
+		}
+
+		tag, ok := retF.Tag.Lookup(FieldTag)
+		if !ok {
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+		for _, option := range options[1:] {
+			if strings.TrimSpace(option) == FieldTagSensitive {
+				colNames = append(colNames, options[0])
+				break
+			}
+		}
+	}
+
+	return colNames
+}
+
 func ReflectColNames(ret reflect.Type) []string {
 	if ret.Kind() == reflect.Ptr {
 		ret = ret.Elem()
@@ -27,7 +85,7 @@ func ReflectColNames(ret reflect.Type) []string {
 			colNames = append(colNames, ReflectColNames(retF.Type)...)
 		}
 
-		if name, ok := retF.Tag.Lookup(FieldTag); ok {
+		if name, ok := LookupColName(retF); ok {
 			colNames = append(colNames, name)
 		}
 	}
@@ -54,7 +112,7 @@ func ReflectInsertColValues(rev reflect.Value) []interface{} {
 		}
 
 		ret := rev.Type()
-		_, ok := ret.Field(i).Tag.Lookup(FieldTag)
+		_, ok := LookupColName(ret.Field(i))
 		if ok {
 			colValues = append(colValues, revF.Interface())
 		}
@@ -82,7 +140,7 @@ func ReflectEntityScanValues(rev reflect.Value) []interface{} {
 			scanValues = append(scanValues, ReflectEntityScanValues(revF)...)
 		}
 
-		_, ok := ret.Field(i).Tag.Lookup(FieldTag)
+		_, ok := LookupColName(ret.Field(i))
 		if ok {
 			scanValues = append(scanValues, revF.Addr().Interface())
 		}
@@ -115,7 +173,7 @@ func ReflectUpdateItems(refOldV, refNewV reflect.Value, updateFields map[string]
 		}
 
 		refNewTF := refNewT.Field(i)
-		colName, ok := refNewTF.Tag.Lookup(FieldTag)
+		colName, ok := LookupColName(refNewTF)
 		if !ok {
 			continue
 		}
@@ -152,7 +210,7 @@ func ReflectQueryItems(rev reflect.Value, required map[string]bool, conditions m
 		}
 
 		retF := ret.Field(i)
-		name, ok := retF.Tag.Lookup(FieldTag)
+		name, ok := LookupColName(retF)
 		if !ok {
 			continue
 		}
@@ -196,3 +254,157 @@ func ReflectQueryRowsToEntityList(rows *sql.Rows, ret reflect.Type, listPtr inte
 
 	return nil
 }
+
+// ReflectColValue returns the value of the field tagged with colName, embedded structs included.
+func ReflectColValue(rev reflect.Value, colName string) (reflect.Value, bool) {
+	if rev.Type().Kind() == reflect.Ptr {
+		if rev.IsNil() {
+			return reflect.Value{}, false
+		}
+		rev = rev.Elem()
+	}
+
+	if rev.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	ret := rev.Type()
+
+	for i := 0; i < rev.NumField(); i++ {
+		revF := rev.Field(i)
+
+		if revF.Kind() == reflect.Ptr || revF.Kind() == reflect.Struct {
+			if v, ok := ReflectColValue(revF, colName); ok {
+				return v, true
+			}
+		}
+
+		name, ok := LookupColName(ret.Field(i))
+		if ok && name == colName {
+			return revF, true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// ReflectSortEntityList sorts the entity pointers in *listPtr by orderBy, e.g. "status, id desc",
+// the way the server would order them, columns unknown to the entity are ignored.
+func ReflectSortEntityList(listPtr interface{}, orderBy string) {
+	items := parseOrderBy(orderBy)
+	if len(items) == 0 {
+		return
+	}
+
+	revListV := reflect.ValueOf(listPtr).Elem()
+
+	sort.SliceStable(revListV.Interface(), func(i, j int) bool {
+		for _, item := range items {
+			vi, oki := ReflectColValue(revListV.Index(i), item.column)
+			vj, okj := ReflectColValue(revListV.Index(j), item.column)
+			if !oki || !okj {
+				continue
+			}
+
+			c := compareValues(vi.Interface(), vj.Interface())
+			if c == 0 {
+				continue
+			}
+			if item.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+
+		return false
+	})
+}
+
+type orderByItem struct {
+	column string
+	desc   bool
+}
+
+func parseOrderBy(orderBy string) []*orderByItem {
+	var items []*orderByItem
+
+	for _, part := range strings.Split(orderBy, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		item := &orderByItem{column: strings.Trim(fields[0], "`")}
+		if len(fields) > 1 && strings.EqualFold(fields[1], "desc") {
+			item.desc = true
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// compareValues orders two column values, nil first, numbers by value and everything else as strings.
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			switch {
+			case ta.Before(tb):
+				return -1
+			case ta.After(tb):
+				return 1
+			}
+			return 0
+		}
+	}
+
+	fa, oka := toFloat64(a)
+	fb, okb := toFloat64(b)
+	if oka && okb {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	return bytes.Compare(toBytes(a), toBytes(b))
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	rev := reflect.ValueOf(v)
+	switch rev.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rev.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rev.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rev.Float(), true
+	}
+
+	return 0, false
+}
+
+func toBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+
+	return []byte(fmt.Sprint(v))
+}
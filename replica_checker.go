@@ -0,0 +1,234 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-jar/golog"
+)
+
+const (
+	DefaultReplicaCheckInterval = 5 * time.Second
+	DefaultReplicaMaxLag        = 10 * time.Second
+	DefaultReplicaMaxFailures   = 3
+
+	DefaultReplicaStatusQuery = "SHOW REPLICA STATUS"
+	DefaultHeartbeatColumn    = "ts"
+)
+
+type ReplicaCheckConfig struct {
+	Interval time.Duration
+	// MaxLag is the lag above which a replica is taken out of rotation.
+	MaxLag time.Duration
+	// MaxFailures is the number of checks failing in a row, e.g. on a timeout, which takes a replica out of rotation.
+	MaxFailures int
+
+	// ReplicaStatusQuery is run when HeartbeatTable is empty, use "SHOW SLAVE STATUS" before MySQL 8.0.22.
+	ReplicaStatusQuery string
+
+	// HeartbeatTable is a table whose HeartbeatColumn is set to NOW(6) on the primary regularly (e.g. by pt-heartbeat),
+	// the lag is the age of its newest row on the replica.
+	HeartbeatTable  string
+	HeartbeatColumn string
+}
+
+func NewReplicaCheckConfig() *ReplicaCheckConfig {
+	return &ReplicaCheckConfig{
+		Interval:           DefaultReplicaCheckInterval,
+		MaxLag:             DefaultReplicaMaxLag,
+		MaxFailures:        DefaultReplicaMaxFailures,
+		ReplicaStatusQuery: DefaultReplicaStatusQuery,
+		HeartbeatColumn:    DefaultHeartbeatColumn,
+	}
+}
+
+// ReplicaChecker measures the lag of every replica of a ClusterPool in the background,
+// it ejects replicas that lag more than MaxLag or fail MaxFailures checks in a row and puts them back once they recover.
+type ReplicaChecker struct {
+	cluster *ClusterPool
+	config  *ReplicaCheckConfig
+	logger  golog.ILogger
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewReplicaChecker(cluster *ClusterPool, config *ReplicaCheckConfig) *ReplicaChecker {
+	if config == nil {
+		config = NewReplicaCheckConfig()
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultReplicaCheckInterval
+	}
+	if config.MaxLag <= 0 {
+		config.MaxLag = DefaultReplicaMaxLag
+	}
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = DefaultReplicaMaxFailures
+	}
+	if config.ReplicaStatusQuery == "" {
+		config.ReplicaStatusQuery = DefaultReplicaStatusQuery
+	}
+	if config.HeartbeatColumn == "" {
+		config.HeartbeatColumn = DefaultHeartbeatColumn
+	}
+
+	return &ReplicaChecker{
+		cluster: cluster,
+		config:  config,
+		logger:  new(golog.NoopLogger),
+	}
+}
+
+func (rc *ReplicaChecker) SetLogger(logger golog.ILogger) *ReplicaChecker {
+	if logger == nil {
+		logger = new(golog.NoopLogger)
+	}
+
+	rc.logger = logger
+	return rc
+}
+
+// Start checks every replica once and then every Interval until Stop is called.
+func (rc *ReplicaChecker) Start() {
+	rc.stopCh = make(chan struct{})
+	rc.wg.Add(1)
+
+	go func() {
+		defer rc.wg.Done()
+
+		ticker := time.NewTicker(rc.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			rc.CheckAll()
+
+			select {
+			case <-rc.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (rc *ReplicaChecker) Stop() {
+	if rc.stopCh == nil {
+		return
+	}
+
+	close(rc.stopCh)
+	rc.wg.Wait()
+	rc.stopCh = nil
+}
+
+// CheckAll checks every replica concurrently and updates the cluster pool.
+func (rc *ReplicaChecker) CheckAll() {
+	var wg sync.WaitGroup
+
+	for name, pool := range rc.cluster.replicaPools() {
+		wg.Add(1)
+
+		go func(name string, pool *Pool) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), rc.config.Interval)
+			defer cancel()
+
+			lag, err := rc.checkReplica(ctx, pool)
+			status, changed := rc.cluster.updateReplica(name, lag, err, rc.config.MaxLag, rc.config.MaxFailures)
+			if changed {
+				if status.Ejected {
+					rc.logger.Log(golog.LevelWarning, []byte("replica "+name+" ejected: "+status.Err.Error()))
+				} else {
+					rc.logger.Log(golog.LevelWarning, []byte("replica "+name+" recovered, lag "+status.Lag.String()))
+				}
+			}
+		}(name, pool)
+	}
+
+	wg.Wait()
+}
+
+func (rc *ReplicaChecker) checkReplica(ctx context.Context, pool *Pool) (time.Duration, error) {
+	client, err := pool.Get()
+	if err != nil {
+		return 0, err
+	}
+	defer pool.Put(client)
+
+	if rc.config.HeartbeatTable != "" {
+		return rc.heartbeatLag(ctx, client)
+	}
+
+	return rc.replicaStatusLag(ctx, client)
+}
+
+func (rc *ReplicaChecker) heartbeatLag(ctx context.Context, client *Client) (time.Duration, error) {
+	query := "SELECT TIMESTAMPDIFF(MICROSECOND, MAX(" + rc.config.HeartbeatColumn + "), NOW(6)) FROM " + rc.config.HeartbeatTable
+
+	var us sql.NullInt64
+	err := client.QueryRowContext(ctx, query).Scan(&us)
+	if err != nil {
+		return 0, err
+	}
+	if !us.Valid {
+		return 0, errors.New("heartbeat table " + rc.config.HeartbeatTable + " is empty")
+	}
+
+	return time.Duration(us.Int64) * time.Microsecond, nil
+}
+
+func (rc *ReplicaChecker) replicaStatusLag(ctx context.Context, client *Client) (time.Duration, error) {
+	rows, err := client.QueryContext(ctx, rc.config.ReplicaStatusQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errors.New("replication is not configured")
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	err = rows.Scan(dest...)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+
+		if !values[i].Valid {
+			return 0, errors.New("replication is not running")
+		}
+
+		seconds, err := strconv.ParseInt(values[i].String, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return 0, errors.New("no Seconds_Behind_Source column in " + rc.config.ReplicaStatusQuery)
+}
@@ -0,0 +1,26 @@
+package mysql
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-jar/golog"
+)
+
+func TestReplicaChecker(t *testing.T) {
+	config := &PoolConfig{NewClientFunc: newMysqlTestClient}
+	config.MaxConns = 10
+	config.MaxIdleTime = time.Second * 5
+
+	cluster := NewClusterPool(NewPool(config)).
+		AddReplica("replica", NewPool(config), 1)
+
+	logger, _ := golog.NewConsoleLogger(golog.LevelInfo)
+	checker := NewReplicaChecker(cluster, NewReplicaCheckConfig()).SetLogger(logger)
+	checker.CheckAll()
+
+	for _, status := range cluster.ReplicaStatuses() {
+		fmt.Println(status.Name, status.Lag, status.Ejected, status.Err)
+	}
+}
@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	ErLockWaitTimeout = 1205
+	ErLockDeadlock    = 1213
+
+	CrServerGoneError = 2006
+	CrServerLost      = 2013
+)
+
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryBaseBackoff = 20 * time.Millisecond
+	DefaultRetryMaxBackoff  = time.Second
+)
+
+// RetryPolicy retries statements failing with a transient error. Reads outside a transaction are retried
+// automatically once a policy is set, writes only with RetryWrites since a lost connection may hide a success.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt too.
+	MaxAttempts int
+	// the n-th retry waits a random duration between half and all of min(BaseBackoff * 2^(n-1), MaxBackoff)
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// RetryableErrors holds the retryable MySQL error numbers, a lost connection is always retryable.
+	RetryableErrors map[uint16]bool
+
+	RetryWrites bool
+	// ReplayTransactions makes WithTransaction run the whole closure again when the transaction fails with
+	// a retryable error such as a deadlock, the closure must then be safe to run several times.
+	ReplayTransactions bool
+}
+
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: DefaultRetryMaxAttempts,
+		BaseBackoff: DefaultRetryBaseBackoff,
+		MaxBackoff:  DefaultRetryMaxBackoff,
+		RetryableErrors: map[uint16]bool{
+			ErLockWaitTimeout: true,
+			ErLockDeadlock:    true,
+			CrServerGoneError: true,
+			CrServerLost:      true,
+		},
+	}
+}
+
+func (rp *RetryPolicy) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return rp.RetryableErrors[me.Number]
+	}
+
+	return false
+}
+
+// Backoff returns the wait before the given retry, starting from 1.
+func (rp *RetryPolicy) Backoff(retry int) time.Duration {
+	d := rp.BaseBackoff
+	for i := 1; i < retry && d < rp.MaxBackoff; i++ {
+		d *= 2
+	}
+	if rp.MaxBackoff > 0 && d > rp.MaxBackoff {
+		d = rp.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// Do runs fn until it succeeds, fails with an error which is not retryable, MaxAttempts is reached or ctx is done.
+func (rp *RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= rp.MaxAttempts || !rp.IsRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(rp.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRetryPolicyDo(t *testing.T) {
+	rp := NewRetryPolicy()
+	rp.BaseBackoff = time.Millisecond
+
+	attempts := 0
+	err := rp.Do(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return &mysql.MySQLError{Number: ErLockDeadlock, Message: "Deadlock found"}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Error("deadlocks should be retried", attempts, err)
+	}
+
+	attempts = 0
+	err = rp.Do(context.Background(), func() error {
+		attempts++
+		return &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	})
+	if err == nil || attempts != 1 {
+		t.Error("duplicate keys should not be retried", attempts)
+	}
+
+	if !rp.IsRetryable(fmt.Errorf("query: %w", driver.ErrBadConn)) {
+		t.Error("a bad connection should be retryable")
+	}
+	if rp.IsRetryable(errors.New("syntax error")) {
+		t.Error("a plain error should not be retryable")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	rp := NewRetryPolicy()
+	rp.BaseBackoff = 100 * time.Millisecond
+	rp.MaxBackoff = 300 * time.Millisecond
+
+	for retry, max := range map[int]time.Duration{1: 100, 2: 200, 3: 300, 10: 300} {
+		max *= time.Millisecond
+		d := rp.Backoff(retry)
+		if d < max/2 || d > max {
+			t.Error("unexpected backoff", retry, d)
+		}
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	client, _ := newMysqlTestClient()
+	policy := NewRetryPolicy()
+	policy.ReplayTransactions = true
+	client.SetRetryPolicy(policy)
+
+	err := client.WithTransaction(func(tx *Client) error {
+		_, err := tx.Exec("update people set age = age + 1 where name = ?", "a")
+		return err
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+}
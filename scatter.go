@@ -0,0 +1,538 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ScatterQuery is a select run on every shard of a table, its OrderBy, Offset and Limit apply to the merged rows.
+// A negative Offset is taken for 0.
+type ScatterQuery struct {
+	What string
+	// Where is the condition of the select, e.g. And(a, Or(b, c)), joined with and to Conditions.
+	Where      CondExpr
+	Conditions []*QueryItem
+	// GroupBy and OrderBy columns must be in What to merge the rows of the shards.
+	GroupBy string
+	OrderBy string
+	Offset  int64
+	Limit   int64
+
+	// MaxConcurrency caps the number of shards queried at the same time, every shard at once when <= 0.
+	MaxConcurrency int
+}
+
+type ScatterResult struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// ScatterError reports the shards that failed, keyed by physical table name.
+type ScatterError struct {
+	Errors map[string]error
+}
+
+func (e *ScatterError) Error() string {
+	tableNames := make([]string, 0, len(e.Errors))
+	for tableName := range e.Errors {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	msgs := make([]string, len(tableNames))
+	for i, tableName := range tableNames {
+		msgs[i] = tableName + ": " + e.Errors[tableName].Error()
+	}
+
+	return "scatter query failed on " + strconv.Itoa(len(msgs)) + " shards: " + strings.Join(msgs, "; ")
+}
+
+// ScatterSelect runs sq on every shard of tableName concurrently and merges the rows: with a GroupBy the rows of
+// the same group are combined, count(...) and sum(...) columns being added up, min(...) and max(...) kept,
+// aliased or not. Selecting avg(...), count(distinct ...) or an expression of aggregates is an error.
+// When some shards fail the rows of the others are returned together with a *ScatterError.
+func (sr *ShardRouter) ScatterSelect(ctx context.Context, tableName string, sq *ScatterQuery) (*ScatterResult, error) {
+	shards, err := sr.Shards(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = scatterAggregates(sq.What)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := sq.MaxConcurrency
+	if concurrency <= 0 || concurrency > len(shards) {
+		concurrency = len(shards)
+	}
+
+	sem := make(chan struct{}, concurrency)
+	results := make([]*ScatterResult, len(shards))
+	errs := make([]error, len(shards))
+
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, shard *Shard) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			results[i], errs[i] = sq.queryShard(ctx, shard)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	var scatterErr *ScatterError
+	var parts []*ScatterResult
+	for i, shard := range shards {
+		if errs[i] != nil {
+			if scatterErr == nil {
+				scatterErr = &ScatterError{Errors: make(map[string]error)}
+			}
+			scatterErr.Errors[shard.TableName] = errs[i]
+			continue
+		}
+		parts = append(parts, results[i])
+	}
+
+	result, err := sq.merge(parts)
+	if err != nil {
+		return nil, err
+	}
+	if scatterErr != nil {
+		return result, scatterErr
+	}
+
+	return result, nil
+}
+
+// ScatterTotalAnd adds up select count(1) of every shard, the way Dao.SelectTotalAnd counts a single table.
+func (sr *ShardRouter) ScatterTotalAnd(ctx context.Context, tableName string, conditions ...*QueryItem) (int64, error) {
+	result, err := sr.ScatterSelect(ctx, tableName, &ScatterQuery{
+		What:       "count(1)",
+		Conditions: conditions,
+	})
+	if result == nil || len(result.Rows) == 0 {
+		return 0, err
+	}
+
+	total, _ := result.Rows[0][0].(int64)
+	return total, err
+}
+
+// ScatterTotalWhere adds up select count(1) of every shard for the rows matching cond, as Dao.SelectTotalWhere.
+func (sr *ShardRouter) ScatterTotalWhere(ctx context.Context, tableName string, cond CondExpr) (int64, error) {
+	result, err := sr.ScatterSelect(ctx, tableName, &ScatterQuery{
+		What:  "count(1)",
+		Where: cond,
+	})
+	if result == nil || len(result.Rows) == 0 {
+		return 0, err
+	}
+
+	total, _ := result.Rows[0][0].(int64)
+	return total, err
+}
+
+// build returns the select sent to the shard table tableName, every shard returns its first Offset+Limit rows.
+func (sq *ScatterQuery) build(tableName string) *QueryBuilder {
+	conds := []CondExpr{sq.Where}
+	for _, condition := range sq.Conditions {
+		conds = append(conds, condition)
+	}
+
+	qb := new(QueryBuilder)
+	qb.Select(tableName, sq.What).
+		Where(conds...).
+		GroupBy(sq.GroupBy).
+		OrderBy(sq.OrderBy)
+	if sq.GroupBy == "" && sq.Limit > 0 {
+		qb.Limit(0, sq.offset()+sq.Limit)
+	}
+
+	return qb
+}
+
+func (sq *ScatterQuery) queryShard(ctx context.Context, shard *Shard) (*ScatterResult, error) {
+	qb := sq.build(shard.TableName)
+
+	client, err := shard.Pool.Get()
+	if err != nil {
+		return nil, err
+	}
+	defer shard.Pool.Put(client)
+
+	rows, err := client.QueryContext(ctx, qb.Query(), qb.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScatterResult{
+		Columns: make([]string, len(columnTypes)),
+	}
+	for i, ct := range columnTypes {
+		result.Columns[i] = ct.Name()
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columnTypes))
+		dest := make([]interface{}, len(columnTypes))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		err = rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, ct := range columnTypes {
+			values[i] = convertScannedValue(values[i], ct)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+
+	return result, rows.Err()
+}
+
+// offset is Offset, a negative one being taken for 0.
+func (sq *ScatterQuery) offset() int64 {
+	if sq.Offset < 0 {
+		return 0
+	}
+
+	return sq.Offset
+}
+
+func (sq *ScatterQuery) merge(parts []*ScatterResult) (*ScatterResult, error) {
+	aggregates, err := scatterAggregates(sq.What)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(ScatterResult)
+	if len(parts) == 0 {
+		return result, nil
+	}
+
+	result.Columns = parts[0].Columns
+	for _, part := range parts {
+		result.Rows = append(result.Rows, part.Rows...)
+	}
+
+	if sq.GroupBy != "" || isAggregateOnly(result.Columns, aggregates) {
+		result.Rows, err = mergeGroups(result.Columns, sq.GroupBy, result.Rows, aggregates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = sortScatterRows(result.Columns, result.Rows, sq.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if sq.Limit > 0 {
+		n := int64(len(result.Rows))
+		start, end := sq.offset(), sq.offset()+sq.Limit
+		if start > n {
+			start = n
+		}
+		if end > n {
+			end = n
+		}
+		result.Rows = result.Rows[start:end]
+	}
+
+	return result, nil
+}
+
+// mergeGroups combines the rows having the same values in the groupBy columns, without groupBy all rows are one group.
+// The groupBy columns must be selected, the rows of the shards could not be told apart otherwise.
+func mergeGroups(columns []string, groupBy string, rows [][]interface{}, aggregates map[string]string) ([][]interface{}, error) {
+	var keyIndexes []int
+	for _, item := range parseOrderBy(groupBy) {
+		i := columnIndex(columns, item.column)
+		if i < 0 {
+			return nil, errors.New("scatter query can not merge the groups of " + item.column + ", select it")
+		}
+		keyIndexes = append(keyIndexes, i)
+	}
+
+	var merged [][]interface{}
+	groups := make(map[string][]interface{})
+
+	for _, row := range rows {
+		var key strings.Builder
+		for _, i := range keyIndexes {
+			key.Write(toBytes(row[i]))
+			key.WriteByte(0)
+		}
+
+		group, ok := groups[key.String()]
+		if !ok {
+			group = append([]interface{}(nil), row...)
+			groups[key.String()] = group
+			merged = append(merged, group)
+			continue
+		}
+
+		for i, column := range columns {
+			group[i] = mergeAggregate(aggregateOf(column, aggregates), group[i], row[i])
+		}
+	}
+
+	return merged, nil
+}
+
+func mergeAggregate(fn string, a, b interface{}) interface{} {
+	if fn == "" || a == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	if b == nil {
+		return a
+	}
+
+	switch fn {
+	case "count", "sum":
+		ia, oka := a.(int64)
+		ib, okb := b.(int64)
+		if oka && okb {
+			return ia + ib
+		}
+
+		fa, _ := toFloat64(a)
+		fb, _ := toFloat64(b)
+		return fa + fb
+	case "min":
+		if compareValues(b, a) < 0 {
+			return b
+		}
+	case "max":
+		if compareValues(b, a) > 0 {
+			return b
+		}
+	}
+
+	return a
+}
+
+var scatterAggregateFuncs = map[string]bool{"count": true, "sum": true, "min": true, "max": true, "avg": true}
+
+// scatterAggregates maps the lower-cased alias of each aliased aggregate of the select list what to its function,
+// e.g. "count(1) as total" to count. The aggregates which can not be combined across shards are rejected:
+// avg, count(distinct ...) and the expressions made of aggregates, e.g. sum(a) / count(1).
+func scatterAggregates(what string) (map[string]string, error) {
+	aggregates := make(map[string]string)
+
+	var item []sqlToken
+	depth := 0
+	for _, token := range append(lexSQL(what), sqlToken{kind: tokPunct, text: ","}) {
+		switch {
+		case token.kind == tokSpace || token.kind == tokComment:
+			continue
+		case token.kind == tokPunct && token.text == "(":
+			depth++
+		case token.kind == tokPunct && token.text == ")":
+			depth--
+		case token.kind == tokPunct && token.text == "," && depth == 0:
+			err := scatterAggregate(item, aggregates)
+			if err != nil {
+				return nil, err
+			}
+			item = nil
+			continue
+		}
+
+		item = append(item, token)
+	}
+
+	return aggregates, nil
+}
+
+func scatterAggregate(item []sqlToken, aggregates map[string]string) error {
+	calls := 0
+	for i := 0; i+1 < len(item); i++ {
+		if item[i].kind == tokIdent && scatterAggregateFuncs[strings.ToLower(item[i].text)] && item[i+1].text == "(" {
+			calls++
+		}
+	}
+	if calls == 0 {
+		return nil
+	}
+
+	text := sqlTokensText(item)
+	fn := strings.ToLower(item[0].text)
+
+	// the aggregate call must be the whole item, but for an alias
+	end := -1
+	if calls == 1 && scatterAggregateFuncs[fn] {
+		depth := 0
+		for i := 1; i < len(item); i++ {
+			if item[i].text == "(" {
+				depth++
+			} else if item[i].text == ")" {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+	}
+
+	rest := item[end+1:]
+	if len(rest) == 2 && isKeyword(rest[0], "as") {
+		rest = rest[1:]
+	}
+	if end < 0 || len(rest) > 1 || (len(rest) == 1 && rest[0].kind != tokIdent && rest[0].kind != tokQuotedIdent) {
+		return errors.New("scatter query can not merge " + text + ", select its aggregates alone")
+	}
+
+	switch {
+	case fn == "avg":
+		return errors.New("scatter query can not merge " + text + ", select sum and count instead")
+	case fn == "count" && len(item) > 2 && isKeyword(item[2], "distinct"):
+		return errors.New("scatter query can not merge " + text + ", distinct counts of the shards can not be added up")
+	}
+
+	if len(rest) == 1 {
+		aggregates[strings.ToLower(strings.Trim(rest[0].text, "`"))] = fn
+	}
+
+	return nil
+}
+
+func sqlTokensText(tokens []sqlToken) string {
+	var b strings.Builder
+	for i, token := range tokens {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token.text)
+	}
+
+	return b.String()
+}
+
+// aggregateOf returns the aggregate function of a result column: the one of its alias, or the one it starts with.
+func aggregateOf(column string, aggregates map[string]string) string {
+	if fn, ok := aggregates[strings.ToLower(column)]; ok {
+		return fn
+	}
+
+	return aggregateFunc(column)
+}
+
+func aggregateFunc(column string) string {
+	column = strings.ToLower(strings.TrimSpace(column))
+	for _, fn := range []string{"count", "sum", "min", "max"} {
+		if strings.HasPrefix(column, fn+"(") {
+			return fn
+		}
+	}
+
+	return ""
+}
+
+func isAggregateOnly(columns []string, aggregates map[string]string) bool {
+	for _, column := range columns {
+		if aggregateOf(column, aggregates) == "" {
+			return false
+		}
+	}
+
+	return len(columns) > 0
+}
+
+func sortScatterRows(columns []string, rows [][]interface{}, orderBy string) error {
+	items := parseOrderBy(orderBy)
+	if len(items) == 0 {
+		return nil
+	}
+
+	indexes := make([]int, len(items))
+	for i, item := range items {
+		indexes[i] = columnIndex(columns, item.column)
+		if indexes[i] < 0 {
+			return errors.New("scatter query can not order the shards by " + item.column + ", select it")
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for k, item := range items {
+			ci := indexes[k]
+			c := compareValues(rows[i][ci], rows[j][ci])
+			if c == 0 {
+				continue
+			}
+			if item.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+
+		return false
+	})
+
+	return nil
+}
+
+func columnIndex(columns []string, name string) int {
+	for i, column := range columns {
+		if strings.EqualFold(column, name) {
+			return i
+		}
+	}
+
+	if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+		return columnIndex(columns, name[dot+1:])
+	}
+
+	return -1
+}
+
+// convertScannedValue turns the []byte of the text protocol into int64, float64 or string according to the column type.
+func convertScannedValue(v interface{}, ct *sql.ColumnType) interface{} {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+
+	s := string(b)
+	switch ct.DatabaseTypeName() {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT":
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return n
+		}
+	case "DECIMAL", "FLOAT", "DOUBLE":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	return s
+}
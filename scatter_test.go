@@ -0,0 +1,146 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestScatterMerge(t *testing.T) {
+	sq := &ScatterQuery{
+		What:    "status, count(1)",
+		GroupBy: "status",
+		OrderBy: "count(1) desc",
+		Limit:   2,
+	}
+
+	columns := []string{"status", "count(1)"}
+	parts := []*ScatterResult{
+		{Columns: columns, Rows: [][]interface{}{{"paid", int64(3)}, {"new", int64(5)}}},
+		{Columns: columns, Rows: [][]interface{}{{"paid", int64(4)}, {"closed", int64(1)}}},
+	}
+
+	result, _ := sq.merge(parts)
+	if len(result.Rows) != 2 {
+		t.Fatal("unexpected rows", result.Rows)
+	}
+	if result.Rows[0][0] != "paid" || result.Rows[0][1] != int64(7) {
+		t.Error("unexpected first row", result.Rows[0])
+	}
+	if result.Rows[1][0] != "new" || result.Rows[1][1] != int64(5) {
+		t.Error("unexpected second row", result.Rows[1])
+	}
+
+	sq = &ScatterQuery{What: "count(1)"}
+	result, _ = sq.merge([]*ScatterResult{
+		{Columns: []string{"count(1)"}, Rows: [][]interface{}{{int64(2)}}},
+		{Columns: []string{"count(1)"}, Rows: [][]interface{}{{int64(3)}}},
+	})
+	if len(result.Rows) != 1 || result.Rows[0][0] != int64(5) {
+		t.Error("unexpected total", result.Rows)
+	}
+
+	sq = &ScatterQuery{What: "*", OrderBy: "id desc", Offset: 1, Limit: 2}
+	result, _ = sq.merge([]*ScatterResult{
+		{Columns: []string{"id"}, Rows: [][]interface{}{{int64(1)}, {int64(9)}}},
+		{Columns: []string{"id"}, Rows: [][]interface{}{{int64(10)}, {int64(2)}}},
+	})
+	if len(result.Rows) != 2 || result.Rows[0][0] != int64(9) || result.Rows[1][0] != int64(2) {
+		t.Error("unexpected page", result.Rows)
+	}
+
+	sq.Offset = -1
+	result, _ = sq.merge([]*ScatterResult{
+		{Columns: []string{"id"}, Rows: [][]interface{}{{int64(1)}, {int64(9)}}},
+	})
+	if len(result.Rows) != 2 || result.Rows[0][0] != int64(9) {
+		t.Error("unexpected page for a negative offset", result.Rows)
+	}
+
+	sq = &ScatterQuery{What: "status, count(1) as total, sum(amount) amount", GroupBy: "status", OrderBy: "status"}
+	columns = []string{"status", "total", "amount"}
+	result, err := sq.merge([]*ScatterResult{
+		{Columns: columns, Rows: [][]interface{}{{"paid", int64(3), int64(30)}}},
+		{Columns: columns, Rows: [][]interface{}{{"paid", int64(4), int64(45)}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0][1] != int64(7) || result.Rows[0][2] != int64(75) {
+		t.Error("unexpected aliased aggregates", result.Rows)
+	}
+
+	sq = &ScatterQuery{What: "count(1)", GroupBy: "status"}
+	_, err = sq.merge([]*ScatterResult{
+		{Columns: []string{"count(1)"}, Rows: [][]interface{}{{int64(2)}, {int64(3)}}},
+		{Columns: []string{"count(1)"}, Rows: [][]interface{}{{int64(4)}}},
+	})
+	if err == nil {
+		t.Error("expect an error for a group by column which is not selected")
+	}
+
+	sq = &ScatterQuery{What: "name", OrderBy: "id desc", Limit: 10}
+	_, err = sq.merge([]*ScatterResult{
+		{Columns: []string{"name"}, Rows: [][]interface{}{{"a"}}},
+		{Columns: []string{"name"}, Rows: [][]interface{}{{"b"}}},
+	})
+	if err == nil {
+		t.Error("expect an error for an order by column which is not selected")
+	}
+
+	for _, what := range []string{"status, avg(amount)", "count(distinct user_id)", "sum(amount) / count(1) as mean"} {
+		sq = &ScatterQuery{What: what}
+		_, err = sq.merge([]*ScatterResult{
+			{Columns: []string{"v"}, Rows: [][]interface{}{{int64(1)}}},
+			{Columns: []string{"v"}, Rows: [][]interface{}{{int64(2)}}},
+		})
+		if err == nil {
+			t.Error("expect an error for", what)
+		}
+	}
+}
+
+func TestScatterQueryBuild(t *testing.T) {
+	sq := &ScatterQuery{
+		What:       "*",
+		Where:      Or(NewCondition("status", CondEqual, "paid"), NewCondition("amount", CondGreater, 100)),
+		Conditions: []*QueryItem{NewCondition("user_id", CondEqual, 7)},
+		OrderBy:    "id desc",
+		Offset:     -5,
+		Limit:      10,
+	}
+
+	qb := sq.build("orders_01")
+	expect := "select * from orders_01 where (status = ?  or amount > ? ) and user_id = ?  order by id desc limit ?, ?"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{"paid", 100, 7, int64(0), int64(10)}) {
+		t.Error(qb.Args())
+	}
+}
+
+func TestScatterSelect(t *testing.T) {
+	router, _ := newTestShardRouter()
+
+	result, err := router.ScatterSelect(context.Background(), "orders", &ScatterQuery{
+		What:           "status, count(1)",
+		GroupBy:        "status",
+		OrderBy:        "status",
+		MaxConcurrency: 8,
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+	if result != nil {
+		fmt.Println(result.Columns, result.Rows)
+	}
+
+	total, err := router.ScatterTotalAnd(context.Background(), "orders", NewCondition("status", CondEqual, "paid"))
+	fmt.Println(total, err)
+
+	total, err = router.ScatterTotalWhere(context.Background(), "orders",
+		Or(NewCondition("status", CondEqual, "paid"), NewCondition("status", CondEqual, "new")))
+	fmt.Println(total, err)
+}
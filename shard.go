@@ -0,0 +1,308 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultShardKeyColumn = "id"
+)
+
+// ShardStrategy maps a shard key to the index of the physical table holding it.
+type ShardStrategy interface {
+	ShardIndex(key interface{}) (int, error)
+}
+
+// ModShardStrategy puts key on table key % Count, the key must be an integer.
+type ModShardStrategy struct {
+	Count int
+}
+
+func (s *ModShardStrategy) ShardIndex(key interface{}) (int, error) {
+	if s.Count <= 0 {
+		return 0, errors.New("mod shard count must be positive, got " + strconv.Itoa(s.Count))
+	}
+
+	n, err := shardKeyToInt64(key)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		n = -n
+	}
+
+	return int(n % int64(s.Count)), nil
+}
+
+// HashShardStrategy puts key on table crc32(key) % Count, it suits string keys such as a user name.
+type HashShardStrategy struct {
+	Count int
+}
+
+func (s *HashShardStrategy) ShardIndex(key interface{}) (int, error) {
+	if s.Count <= 0 {
+		return 0, errors.New("hash shard count must be positive, got " + strconv.Itoa(s.Count))
+	}
+
+	var b []byte
+	switch v := key.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		b = []byte(fmt.Sprint(key))
+	}
+
+	return int(crc32.ChecksumIEEE(b) % uint32(s.Count)), nil
+}
+
+// RangeShardStrategy puts key on the first table i with key < Bounds[i], Bounds must be ascending.
+type RangeShardStrategy struct {
+	Bounds []int64
+}
+
+func (s *RangeShardStrategy) ShardIndex(key interface{}) (int, error) {
+	n, err := shardKeyToInt64(key)
+	if err != nil {
+		return 0, err
+	}
+
+	i := sort.Search(len(s.Bounds), func(i int) bool {
+		return n < s.Bounds[i]
+	})
+	if i == len(s.Bounds) {
+		return 0, errors.New("shard key " + strconv.FormatInt(n, 10) + " is out of range")
+	}
+
+	return i, nil
+}
+
+const (
+	ShardByDay = iota
+	ShardByMonth
+	ShardByYear
+)
+
+// DateShardStrategy puts a time.Time key on one table per day, month or year counted from Start,
+// use its TableName as ShardRule.TableNameFunc to name the tables after the date, e.g. orders_202601 with Layout "200601".
+type DateShardStrategy struct {
+	Start  time.Time
+	Unit   int
+	Layout string
+}
+
+func (s *DateShardStrategy) ShardIndex(key interface{}) (int, error) {
+	t, ok := key.(time.Time)
+	if !ok {
+		return 0, fmt.Errorf("date shard key must be time.Time, got %T", key)
+	}
+
+	t = t.In(s.Start.Location())
+	if t.Before(s.Start) {
+		return 0, errors.New("shard key " + t.String() + " is before " + s.Start.String())
+	}
+
+	switch s.Unit {
+	case ShardByYear:
+		return t.Year() - s.Start.Year(), nil
+	case ShardByMonth:
+		return (t.Year()-s.Start.Year())*12 + int(t.Month()) - int(s.Start.Month()), nil
+	default:
+		// count calendar days, a day across a DST change is not 24 hours
+		start := time.Date(s.Start.Year(), s.Start.Month(), s.Start.Day(), 0, 0, 0, 0, time.UTC)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return int(day.Sub(start) / (24 * time.Hour)), nil
+	}
+}
+
+func (s *DateShardStrategy) TableName(tableName string, index int) string {
+	var t time.Time
+	switch s.Unit {
+	case ShardByYear:
+		t = s.Start.AddDate(index, 0, 0)
+	case ShardByMonth:
+		t = time.Date(s.Start.Year(), s.Start.Month()+time.Month(index), 1, 0, 0, 0, 0, s.Start.Location())
+	default:
+		t = s.Start.AddDate(0, 0, index)
+	}
+
+	return tableName + "_" + t.Format(s.Layout)
+}
+
+type ShardRule struct {
+	Strategy ShardStrategy
+	// KeyColumn is the column holding the shard key, DefaultShardKeyColumn when empty.
+	KeyColumn string
+	// ShardCount is the number of physical tables, it may be 0 when tables are added over time, e.g. by date.
+	ShardCount int
+	// Pools holds the instances the tables are spread over: with ShardCount table i lives on
+	// Pools[i*len(Pools)/ShardCount], so orders_00..orders_63 over 4 pools puts 16 consecutive tables on each,
+	// without it table i lives on Pools[i%len(Pools)].
+	Pools []*Pool
+	// TableNameFunc names the physical tables, tableName_00, tableName_01... when nil.
+	TableNameFunc func(tableName string, index int) string
+}
+
+func (sr *ShardRule) keyColumn() string {
+	if sr.KeyColumn == "" {
+		return DefaultShardKeyColumn
+	}
+
+	return sr.KeyColumn
+}
+
+func (sr *ShardRule) shard(tableName string, index int) (*Shard, error) {
+	if index < 0 || (sr.ShardCount > 0 && index >= sr.ShardCount) {
+		return nil, errors.New("shard index " + strconv.Itoa(index) + " of table " + tableName + " is out of range")
+	}
+	if len(sr.Pools) == 0 {
+		return nil, errors.New("no pool for sharded table " + tableName)
+	}
+
+	var pool *Pool
+	if sr.ShardCount > 0 {
+		pool = sr.Pools[index*len(sr.Pools)/sr.ShardCount]
+	} else {
+		pool = sr.Pools[index%len(sr.Pools)]
+	}
+
+	var physical string
+	if sr.TableNameFunc != nil {
+		physical = sr.TableNameFunc(tableName, index)
+	} else {
+		physical = fmt.Sprintf("%s_%02d", tableName, index)
+	}
+
+	return &Shard{
+		Index:     index,
+		Pool:      pool,
+		TableName: physical,
+	}, nil
+}
+
+// Shard is a physical table and the pool of the instance holding it.
+type Shard struct {
+	Index     int
+	Pool      *Pool
+	TableName string
+}
+
+type ShardIds struct {
+	*Shard
+
+	Ids []int64
+}
+
+// ShardRouter turns a logical table name and a shard key into a Shard, tables without a rule are not sharded.
+type ShardRouter struct {
+	rules map[string]*ShardRule
+}
+
+func NewShardRouter() *ShardRouter {
+	return &ShardRouter{
+		rules: make(map[string]*ShardRule),
+	}
+}
+
+func (sr *ShardRouter) AddRule(tableName string, rule *ShardRule) *ShardRouter {
+	sr.rules[tableName] = rule
+	return sr
+}
+
+func (sr *ShardRouter) Rule(tableName string) (*ShardRule, bool) {
+	rule, ok := sr.rules[tableName]
+	return rule, ok
+}
+
+func (sr *ShardRouter) Route(tableName string, key interface{}) (*Shard, error) {
+	rule, ok := sr.rules[tableName]
+	if !ok {
+		return nil, errors.New("table " + tableName + " is not sharded")
+	}
+
+	index, err := rule.Strategy.ShardIndex(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule.shard(tableName, index)
+}
+
+// Shards returns every shard of tableName, its rule must have a ShardCount.
+func (sr *ShardRouter) Shards(tableName string) ([]*Shard, error) {
+	rule, ok := sr.rules[tableName]
+	if !ok {
+		return nil, errors.New("table " + tableName + " is not sharded")
+	}
+	if rule.ShardCount <= 0 {
+		return nil, errors.New("sharded table " + tableName + " has no ShardCount")
+	}
+
+	shards := make([]*Shard, rule.ShardCount)
+	for i := range shards {
+		shard, err := rule.shard(tableName, i)
+		if err != nil {
+			return nil, err
+		}
+		shards[i] = shard
+	}
+
+	return shards, nil
+}
+
+// GroupIds routes ids by tableName's rule, which must be keyed on the id column, and groups them per shard in shard order.
+func (sr *ShardRouter) GroupIds(tableName string, ids []int64) ([]*ShardIds, error) {
+	rule, ok := sr.rules[tableName]
+	if !ok {
+		return nil, errors.New("table " + tableName + " is not sharded")
+	}
+	if rule.keyColumn() != DefaultShardKeyColumn {
+		return nil, errors.New("table " + tableName + " is sharded by " + rule.keyColumn() + ", not by id")
+	}
+
+	groups := make(map[int]*ShardIds)
+	for _, id := range ids {
+		shard, err := sr.Route(tableName, id)
+		if err != nil {
+			return nil, err
+		}
+
+		group, ok := groups[shard.Index]
+		if !ok {
+			group = &ShardIds{Shard: shard}
+			groups[shard.Index] = group
+		}
+		group.Ids = append(group.Ids, id)
+	}
+
+	result := make([]*ShardIds, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Index < result[j].Index
+	})
+
+	return result, nil
+}
+
+func shardKeyToInt64(key interface{}) (int64, error) {
+	rev := reflect.ValueOf(key)
+	switch rev.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rev.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rev.Uint()), nil
+	case reflect.String:
+		return strconv.ParseInt(rev.String(), 10, 64)
+	}
+
+	return 0, fmt.Errorf("shard key must be an integer, got %T", key)
+}
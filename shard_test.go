@@ -0,0 +1,135 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestShardRouter() (*ShardRouter, []*Pool) {
+	pools := []*Pool{
+		NewPool(&PoolConfig{NewClientFunc: newMysqlTestClient}),
+		NewPool(&PoolConfig{NewClientFunc: newMysqlTestClient}),
+	}
+
+	router := NewShardRouter().AddRule("orders", &ShardRule{
+		Strategy:   &ModShardStrategy{Count: 64},
+		ShardCount: 64,
+		Pools:      pools,
+	})
+
+	return router, pools
+}
+
+func TestShardRouterRoute(t *testing.T) {
+	router, pools := newTestShardRouter()
+
+	shard, err := router.Route("orders", int64(70))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shard.TableName != "orders_06" || shard.Pool != pools[0] {
+		t.Error("unexpected shard", shard.TableName)
+	}
+
+	shard, _ = router.Route("orders", int64(63))
+	if shard.TableName != "orders_63" || shard.Pool != pools[1] {
+		t.Error("unexpected shard", shard.TableName)
+	}
+
+	if _, err = router.Route("users", 1); err == nil {
+		t.Error("users is not sharded")
+	}
+}
+
+func TestShardRouterGroupIds(t *testing.T) {
+	router, _ := newTestShardRouter()
+
+	groups, err := router.GroupIds("orders", []int64{65, 1, 2, 130})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].Index != 1 || groups[1].Index != 2 {
+		t.Fatal("unexpected groups", groups)
+	}
+	if len(groups[0].Ids) != 2 || groups[0].Ids[0] != 65 || groups[0].Ids[1] != 1 {
+		t.Error("unexpected ids", groups[0].Ids)
+	}
+}
+
+func TestShardStrategies(t *testing.T) {
+	rs := &RangeShardStrategy{Bounds: []int64{100, 200}}
+	if i, _ := rs.ShardIndex(150); i != 1 {
+		t.Error("unexpected range shard", i)
+	}
+	if _, err := rs.ShardIndex(200); err == nil {
+		t.Error("200 is out of range")
+	}
+
+	hs := &HashShardStrategy{Count: 8}
+	i1, _ := hs.ShardIndex("tdj")
+	i2, _ := hs.ShardIndex("tdj")
+	if i1 != i2 || i1 < 0 || i1 >= 8 {
+		t.Error("unexpected hash shard", i1, i2)
+	}
+
+	if _, err := (&HashShardStrategy{}).ShardIndex("tdj"); err == nil {
+		t.Error("a zero hash shard count must fail")
+	}
+	if _, err := (&ModShardStrategy{}).ShardIndex(7); err == nil {
+		t.Error("a zero mod shard count must fail")
+	}
+
+	ds := &DateShardStrategy{
+		Start:  time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+		Unit:   ShardByMonth,
+		Layout: "200601",
+	}
+	i, err := ds.ShardIndex(time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil || i != 3 {
+		t.Error("unexpected date shard", i, err)
+	}
+	if name := ds.TableName("orders", i); name != "orders_202602" {
+		t.Error("unexpected date table", name)
+	}
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	ds = &DateShardStrategy{
+		Start:  time.Date(2026, 1, 1, 0, 0, 0, 0, loc),
+		Unit:   ShardByDay,
+		Layout: "20060102",
+	}
+	i, err = ds.ShardIndex(time.Date(2026, 3, 9, 0, 30, 0, 0, loc))
+	if err != nil || i != 67 {
+		t.Error("unexpected day shard after DST", i, err)
+	}
+	if name := ds.TableName("orders", i); name != "orders_20260309" {
+		t.Error("unexpected day table", name)
+	}
+}
+
+func TestShardInsertError(t *testing.T) {
+	err := error(&ShardInsertError{Inserted: []string{"orders_00", "orders_01"}, Failed: "orders_02", Err: ErrDuplicateKey})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Error("ShardInsertError must unwrap to its cause")
+	}
+	if err.Error() != "sharded insert failed on orders_02 after inserting into [orders_00, orders_01]: mysql: duplicate key" {
+		t.Error(err)
+	}
+}
+
+func TestReflectSortEntityList(t *testing.T) {
+	list := []*demoEntity{
+		{SqlBaseEntity: SqlBaseEntity{Id: 1}, Status: 2},
+		{SqlBaseEntity: SqlBaseEntity{Id: 2}, Status: 1},
+		{SqlBaseEntity: SqlBaseEntity{Id: 3}, Status: 2},
+	}
+
+	ReflectSortEntityList(&list, "status, id desc")
+	if list[0].Id != 2 || list[1].Id != 3 || list[2].Id != 1 {
+		t.Error("unexpected order", list[0].Id, list[1].Id, list[2].Id)
+	}
+}
@@ -1,20 +1,48 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/go-jar/golog"
 	"reflect"
+	"strings"
 )
 
+var ErrNotInTransaction = errors.New("locking read outside of a transaction")
+
+// ShardInsertError reports a sharded insert which failed on table Failed after the rows of the tables in Inserted
+// were written, those rows stay unless the insert ran in a transaction.
+type ShardInsertError struct {
+	Inserted []string
+	Failed   string
+	Err      error
+}
+
+func (e *ShardInsertError) Error() string {
+	return "sharded insert failed on " + e.Failed + " after inserting into [" + strings.Join(e.Inserted, ", ") + "]: " + e.Err.Error()
+}
+
+func (e *ShardInsertError) Unwrap() error {
+	return e.Err
+}
+
 type SimpleOrm struct {
 	pool *Pool
 	dao  *Dao
 
+	cluster      *ClusterPool
+	replicaPool  *Pool
+	forcePrimary bool
+
+	router *ShardRouter
+	tracer Tracer
+
 	idGenerator *IdGenerator
 	traceId     []byte
 	logger      golog.ILogger
 	useIdGen    bool
+	txDepth     int
 }
 
 func NewSimpleOrm(traceId []byte, pool *Pool, useIdGen bool) *SimpleOrm {
@@ -26,6 +54,14 @@ func NewSimpleOrm(traceId []byte, pool *Pool, useIdGen bool) *SimpleOrm {
 	}
 }
 
+// NewClusterSimpleOrm returns an orm which writes to the primary of cluster and reads from its replicas.
+func NewClusterSimpleOrm(traceId []byte, cluster *ClusterPool, useIdGen bool) *SimpleOrm {
+	so := NewSimpleOrm(traceId, cluster.Primary(), useIdGen)
+	so.cluster = cluster
+
+	return so
+}
+
 type QueryParams struct {
 	ParamsStructPtr interface{}
 	Required        map[string]bool
@@ -49,6 +85,31 @@ func (so *SimpleOrm) Dao() *Dao {
 	return so.dao
 }
 
+// ReadDao returns the dao used by reads. With a cluster pool its reads go to a replica client,
+// unless the orm is inside a transaction or forced to the primary, in which case it is Dao().
+func (so *SimpleOrm) ReadDao() *Dao {
+	if so.cluster == nil || so.forcePrimary || so.inTransaction() {
+		return so.Dao()
+	}
+
+	if so.dao == nil {
+		so.dao = &Dao{}
+	}
+
+	if so.dao.replica == nil {
+		replicaPool := so.cluster.Replica()
+		replica, err := replicaPool.Get()
+		if err != nil {
+			return so.Dao()
+		}
+
+		so.replicaPool = replicaPool
+		so.dao.replica = replica.SetLogger(so.logger).SetTraceId(so.traceId)
+	}
+
+	return so.dao
+}
+
 func (so *SimpleOrm) IdGenerator() *IdGenerator {
 	if !so.useIdGen {
 		return nil
@@ -71,13 +132,31 @@ func (so *SimpleOrm) SetLogger(logger golog.ILogger) *SimpleOrm {
 	return so
 }
 
+// SetForcePrimary sends the reads of a cluster orm to the primary, e.g. to read your own writes.
+func (so *SimpleOrm) SetForcePrimary(forcePrimary bool) *SimpleOrm {
+	so.forcePrimary = forcePrimary
+	return so
+}
+
+// SetShardRouter makes Insert, GetById, UpdateById, ListByIds and ListByIdsLimit route the tables
+// which have a rule in router to their physical table and pool, other tables keep using the orm's pool.
+func (so *SimpleOrm) SetShardRouter(router *ShardRouter) *SimpleOrm {
+	so.router = router
+	return so
+}
+
+// SetTracer makes each operation of the orm start a span named after its method, parent of the spans of its statements.
+func (so *SimpleOrm) SetTracer(tracer Tracer) *SimpleOrm {
+	so.tracer = tracer
+	return so
+}
+
 func (so *SimpleOrm) Renew(traceId []byte, pool *Pool) *SimpleOrm {
-	if so.dao != nil && so.dao.Client != nil {
-		so.PutBackClient()
-	}
+	so.PutBackClient()
 
 	so.traceId = traceId
 	so.pool = pool
+	so.cluster = nil
 
 	return so
 }
@@ -86,7 +165,32 @@ func (so *SimpleOrm) SetPool(pool *Pool) *SimpleOrm {
 	return so.Renew(so.traceId, pool)
 }
 
+func (so *SimpleOrm) SetClusterPool(cluster *ClusterPool) *SimpleOrm {
+	so.Renew(so.traceId, cluster.Primary())
+	so.cluster = cluster
+
+	return so
+}
+
 func (so *SimpleOrm) PutBackClient() {
+	if so.inTransaction() || so.dao == nil {
+		return
+	}
+
+	if so.dao.replica != nil {
+		if !so.dao.replica.IsClosed() {
+			so.dao.replica.SetLogger(new(golog.NoopLogger))
+			_ = so.replicaPool.Put(so.dao.replica)
+		}
+
+		so.dao.replica = nil
+		so.replicaPool = nil
+	}
+
+	if so.dao.Client == nil {
+		return
+	}
+
 	if !so.dao.Client.IsClosed() {
 		so.dao.Client.SetLogger(new(golog.NoopLogger))
 		_ = so.pool.Put(so.dao.Client)
@@ -98,12 +202,45 @@ func (so *SimpleOrm) PutBackClient() {
 	}
 }
 
+// inTransaction tells whether the orm runs in a transaction, begun by Transaction or on the client of its Dao,
+// in which case every call keeps that client.
+func (so *SimpleOrm) inTransaction() bool {
+	return so.txDepth > 0 || (so.dao != nil && so.dao.Client != nil && so.dao.Client.InTransaction())
+}
+
+// Transaction runs fn in a transaction on one pooled client, every SimpleOrm call made by fn joins it.
+// Nested calls use savepoints, the client goes back into the pool only after the outermost transaction ends.
+func (so *SimpleOrm) Transaction(fn func(*SimpleOrm) error) error {
+	return so.TransactionCtx(context.Background(), fn)
+}
+
+func (so *SimpleOrm) TransactionCtx(ctx context.Context, fn func(*SimpleOrm) error) (err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.Transaction", "")
+	defer func() { endSpan(span, err) }()
+
+	client := so.Dao().Client
+	so.txDepth++
+
+	defer func() {
+		so.txDepth--
+		so.PutBackClient()
+	}()
+
+	return client.WithTransactionCtx(ctx, func(*Client) error {
+		return fn(so)
+	})
+}
+
 func (so *SimpleOrm) FillEntityForInsert(rev reflect.Value, entityName, idFieldName string) (int64, error) {
+	return so.FillEntityForInsertCtx(context.Background(), rev, entityName, idFieldName)
+}
+
+func (so *SimpleOrm) FillEntityForInsertCtx(ctx context.Context, rev reflect.Value, entityName, idFieldName string) (int64, error) {
 	var id int64
 	var err error
 
 	if so.useIdGen {
-		id, err = so.IdGenerator().GenerateId(entityName)
+		id, err = so.IdGenerator().GenerateIdCtx(ctx, entityName)
 		if err != nil {
 			return -1, err
 		}
@@ -113,14 +250,23 @@ func (so *SimpleOrm) FillEntityForInsert(rev reflect.Value, entityName, idFieldN
 	return id, nil
 }
 
+// Insert writes entities to tableName, with a shard rule the entities are grouped per shard and written one shard
+// after another: it is not atomic across shards unless run in a transaction of a single instance, a failure returns
+// a *ShardInsertError naming the tables already written.
 func (so *SimpleOrm) Insert(tableName, entityName, idFieldName string, entities ...interface{}) ([]int64, error) {
+	return so.InsertCtx(context.Background(), tableName, entityName, idFieldName, entities...)
+}
+
+func (so *SimpleOrm) InsertCtx(ctx context.Context, tableName, entityName, idFieldName string, entities ...interface{}) (ids []int64, err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.Insert", tableName)
+	defer func() { endSpan(span, err) }()
+
 	cnt := len(entities)
 	if cnt <= 0 {
 		return nil, errors.New("no values to be inserted")
 	}
 
 	colsValues := make([][]interface{}, cnt)
-	var ids []int64
 
 	for i, entity := range entities {
 		rev := reflect.ValueOf(entity)
@@ -128,7 +274,7 @@ func (so *SimpleOrm) Insert(tableName, entityName, idFieldName string, entities
 			rev = rev.Elem()
 		}
 
-		id, err := so.FillEntityForInsert(rev, entityName, idFieldName)
+		id, err := so.FillEntityForInsertCtx(ctx, rev, entityName, idFieldName)
 		if err != nil {
 			return nil, err
 		}
@@ -141,38 +287,160 @@ func (so *SimpleOrm) Insert(tableName, entityName, idFieldName string, entities
 	ret := reflect.TypeOf(entity)
 	colNames := ReflectColNames(ret)
 
-	execResult := so.Dao().Insert(tableName, colNames, colsValues...)
+	rule, ok := so.shardRule(tableName)
+	if !ok {
+		return ids, so.insert(ctx, tableName, colNames, colsValues)
+	}
 
-	defer so.PutBackClient()
+	var shards []*Shard
+	shardsValues := make(map[int][][]interface{})
 
-	if execResult.Err != nil {
-		return nil, execResult.Err
+	for i, entity := range entities {
+		key, ok := ReflectColValue(reflect.ValueOf(entity), rule.keyColumn())
+		if !ok || key.IsZero() {
+			return nil, errors.New("shard key " + rule.keyColumn() + " of table " + tableName + " is not set")
+		}
+
+		shard, err := so.router.Route(tableName, key.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := shardsValues[shard.Index]; !ok {
+			shards = append(shards, shard)
+		}
+		shardsValues[shard.Index] = append(shardsValues[shard.Index], colsValues[i])
+	}
+
+	var inserted []string
+	for _, shard := range shards {
+		err := so.onShard(shard, func() error {
+			return so.insert(ctx, shard.TableName, colNames, shardsValues[shard.Index])
+		})
+		if err != nil {
+			return nil, &ShardInsertError{Inserted: inserted, Failed: shard.TableName, Err: err}
+		}
+		inserted = append(inserted, shard.TableName)
 	}
 
 	return ids, nil
 }
 
+func (so *SimpleOrm) insert(ctx context.Context, tableName string, colNames []string, colsValues [][]interface{}) error {
+	execResult := so.Dao().InsertCtx(ctx, tableName, colNames, colsValues...)
+
+	defer so.PutBackClient()
+
+	return execResult.Err
+}
+
 func (so *SimpleOrm) GetById(tableName string, id int64, entityPtr interface{}) (bool, error) {
+	return so.GetByIdCtx(context.Background(), tableName, id, entityPtr)
+}
+
+func (so *SimpleOrm) GetByIdCtx(ctx context.Context, tableName string, id int64, entityPtr interface{}) (find bool, err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.GetById", tableName)
+	defer func() { endSpan(span, err) }()
+
+	shard, err := so.shardById(tableName, id)
+	if err != nil {
+		return false, err
+	}
+	if shard == nil {
+		return so.getById(ctx, so.ReadDao(), tableName, id, entityPtr, false)
+	}
+
+	err = so.onShard(shard, func() error {
+		find, err = so.getById(ctx, so.ReadDao(), shard.TableName, id, entityPtr, false)
+		return err
+	})
+
+	return find, err
+}
+
+// GetByIdForUpdate reads and locks the row until the end of the transaction, out of one it returns ErrNotInTransaction.
+func (so *SimpleOrm) GetByIdForUpdate(tableName string, id int64, entityPtr interface{}) (bool, error) {
+	return so.GetByIdForUpdateCtx(context.Background(), tableName, id, entityPtr)
+}
+
+func (so *SimpleOrm) GetByIdForUpdateCtx(ctx context.Context, tableName string, id int64, entityPtr interface{}) (find bool, err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.GetByIdForUpdate", tableName)
+	defer func() { endSpan(span, err) }()
+
+	if !so.inTransaction() {
+		return false, ErrNotInTransaction
+	}
+
+	shard, err := so.shardById(tableName, id)
+	if err != nil {
+		return false, err
+	}
+	if shard == nil {
+		return so.getById(ctx, so.Dao(), tableName, id, entityPtr, true)
+	}
+
+	err = so.onShard(shard, func() error {
+		find, err = so.getById(ctx, so.Dao(), shard.TableName, id, entityPtr, true)
+		return err
+	})
+
+	return find, err
+}
+
+func (so *SimpleOrm) getById(ctx context.Context, dao *Dao, tableName string, id int64, entityPtr interface{}, forUpdate bool) (bool, error) {
 	scanValues := ReflectEntityScanValues(reflect.ValueOf(entityPtr).Elem())
 
-	err := so.Dao().SelectById(tableName, "*", id).Scan(scanValues...)
+	var row *sql.Row
+	if forUpdate {
+		row = dao.SelectByIdForUpdateCtx(ctx, tableName, "*", id)
+	} else {
+		row = dao.SelectByIdCtx(ctx, tableName, "*", id)
+	}
+
+	err := row.Scan(scanValues...)
 	defer so.PutBackClient()
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, nil
 		}
-		return false, err
+		return false, ClassifyError(err)
 	}
 
 	return true, nil
 }
 
 func (so *SimpleOrm) UpdateById(tableName string, id int64, newEntityPtr interface{}, updateFields map[string]bool) ([]*QueryItem, error) {
+	return so.UpdateByIdCtx(context.Background(), tableName, id, newEntityPtr, updateFields)
+}
+
+func (so *SimpleOrm) UpdateByIdCtx(ctx context.Context, tableName string, id int64, newEntityPtr interface{}, updateFields map[string]bool) (setItems []*QueryItem, err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.UpdateById", tableName)
+	defer func() { endSpan(span, err) }()
+
+	shard, err := so.shardById(tableName, id)
+	if err != nil {
+		return nil, err
+	}
+	if shard == nil {
+		return so.updateById(ctx, tableName, id, newEntityPtr, updateFields)
+	}
+
+	err = so.onShard(shard, func() error {
+		setItems, err = so.updateById(ctx, shard.TableName, id, newEntityPtr, updateFields)
+		return err
+	})
+
+	return setItems, err
+}
+
+func (so *SimpleOrm) updateById(ctx context.Context, tableName string, id int64, newEntityPtr interface{}, updateFields map[string]bool) ([]*QueryItem, error) {
 	rev := reflect.ValueOf(newEntityPtr).Elem()
 	oldEntity := reflect.New(rev.Type()).Interface()
 
-	find, err := so.GetById(tableName, id, oldEntity)
+	// the old entity is read from the primary, a lagging replica would produce wrong set items,
+	// and locked inside a transaction so that no concurrent update slips in before ours
+	find, err := so.getById(ctx, so.Dao(), tableName, id, oldEntity, so.inTransaction())
 	if err != nil {
 		return nil, err
 	}
@@ -185,7 +453,7 @@ func (so *SimpleOrm) UpdateById(tableName string, id int64, newEntityPtr interfa
 		return nil, nil
 	}
 
-	result := so.Dao().UpdateById(tableName, id, setItems...)
+	result := so.Dao().UpdateByIdCtx(ctx, tableName, id, setItems...)
 	defer so.PutBackClient()
 
 	if result.Err != nil {
@@ -198,8 +466,91 @@ func (so *SimpleOrm) UpdateById(tableName string, id int64, newEntityPtr interfa
 	return setItems, nil
 }
 
+// Save inserts entity or, when it hits a primary or unique key, updates the other columns of the existing row.
+// The id is generated only when the orm uses the id generator and the id field of entity is 0.
+func (so *SimpleOrm) Save(tableName, entityName, idFieldName string, entity interface{}) (UpsertResult, error) {
+	return so.SaveCtx(context.Background(), tableName, entityName, idFieldName, entity)
+}
+
+func (so *SimpleOrm) SaveCtx(ctx context.Context, tableName, entityName, idFieldName string, entity interface{}) (result UpsertResult, err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.Save", tableName)
+	defer func() { endSpan(span, err) }()
+
+	rev := reflect.ValueOf(entity)
+	if rev.Kind() == reflect.Ptr {
+		rev = rev.Elem()
+	}
+
+	if so.useIdGen && rev.FieldByName(idFieldName).Int() == 0 {
+		_, err = so.FillEntityForInsertCtx(ctx, rev, entityName, idFieldName)
+		if err != nil {
+			return UpsertUnchanged, err
+		}
+	}
+
+	colNames := ReflectColNames(rev.Type())
+	colValues := ReflectInsertColValues(rev)
+
+	rule, ok := so.shardRule(tableName)
+	keyColumn := DefaultShardKeyColumn
+	if ok {
+		keyColumn = rule.keyColumn()
+	}
+
+	var updateColNames []string
+	for _, colName := range colNames {
+		if colName != "id" && colName != keyColumn {
+			updateColNames = append(updateColNames, colName)
+		}
+	}
+
+	if !ok {
+		return so.upsert(ctx, tableName, colNames, colValues, updateColNames)
+	}
+
+	key, found := ReflectColValue(rev, keyColumn)
+	if !found || key.IsZero() {
+		return UpsertUnchanged, errors.New("shard key " + keyColumn + " of table " + tableName + " is not set")
+	}
+
+	shard, err := so.router.Route(tableName, key.Interface())
+	if err != nil {
+		return UpsertUnchanged, err
+	}
+
+	err = so.onShard(shard, func() error {
+		result, err = so.upsert(ctx, shard.TableName, colNames, colValues, updateColNames)
+		return err
+	})
+
+	return result, err
+}
+
+func (so *SimpleOrm) upsert(ctx context.Context, tableName string, colNames []string, colValues []interface{}, updateColNames []string) (UpsertResult, error) {
+	execResult := so.Dao().UpsertCtx(ctx, tableName, colNames, [][]interface{}{colValues}, updateColNames...)
+
+	defer so.PutBackClient()
+
+	if execResult.Err != nil {
+		return UpsertUnchanged, execResult.Err
+	}
+
+	return UpsertResultOf(execResult.RowsAffected), nil
+}
+
 func (so *SimpleOrm) ListByIds(tableName string, ids []int64, orderBy string, entityType reflect.Type, listPtr interface{}) error {
-	rows, err := so.Dao().SelectByIds(tableName, "*", orderBy, ids...)
+	return so.ListByIdsCtx(context.Background(), tableName, ids, orderBy, entityType, listPtr)
+}
+
+func (so *SimpleOrm) ListByIdsCtx(ctx context.Context, tableName string, ids []int64, orderBy string, entityType reflect.Type, listPtr interface{}) (err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.ListByIds", tableName)
+	defer func() { endSpan(span, err) }()
+
+	if _, ok := so.shardRule(tableName); ok {
+		return so.listByIdsOnShards(ctx, tableName, ids, orderBy, entityType, listPtr)
+	}
+
+	rows, err := so.ReadDao().SelectByIdsCtx(ctx, tableName, "*", orderBy, ids...)
 	defer so.PutBackClient()
 
 	if err != nil {
@@ -210,7 +561,36 @@ func (so *SimpleOrm) ListByIds(tableName string, ids []int64, orderBy string, en
 }
 
 func (so *SimpleOrm) ListByIdsLimit(tableName string, ids []int64, orderBy string, offset, limit int64, entityType reflect.Type, listPtr interface{}) error {
-	rows, err := so.Dao().SelectByIdsLimit(tableName, "*", orderBy, offset, limit, ids...)
+	return so.ListByIdsLimitCtx(context.Background(), tableName, ids, orderBy, offset, limit, entityType, listPtr)
+}
+
+func (so *SimpleOrm) ListByIdsLimitCtx(ctx context.Context, tableName string, ids []int64, orderBy string, offset, limit int64, entityType reflect.Type, listPtr interface{}) (err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.ListByIdsLimit", tableName)
+	defer func() { endSpan(span, err) }()
+
+	if _, ok := so.shardRule(tableName); ok {
+		err := so.listByIdsOnShards(ctx, tableName, ids, orderBy, entityType, listPtr)
+		if err != nil {
+			return err
+		}
+
+		revListV := reflect.ValueOf(listPtr).Elem()
+		n := int64(revListV.Len())
+		if offset < 0 || limit <= 0 {
+			return nil
+		}
+		if offset > n {
+			offset = n
+		}
+		if offset+limit > n {
+			limit = n - offset
+		}
+		revListV.Set(revListV.Slice(int(offset), int(offset+limit)))
+
+		return nil
+	}
+
+	rows, err := so.ReadDao().SelectByIdsLimitCtx(ctx, tableName, "*", orderBy, offset, limit, ids...)
 	defer so.PutBackClient()
 
 	if err != nil {
@@ -221,13 +601,20 @@ func (so *SimpleOrm) ListByIdsLimit(tableName string, ids []int64, orderBy strin
 }
 
 func (so *SimpleOrm) SimpleQueryAnd(tableName string, qp *QueryParams, entityType reflect.Type, listPtr interface{}) error {
+	return so.SimpleQueryAndCtx(context.Background(), tableName, qp, entityType, listPtr)
+}
+
+func (so *SimpleOrm) SimpleQueryAndCtx(ctx context.Context, tableName string, qp *QueryParams, entityType reflect.Type, listPtr interface{}) (err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.SimpleQueryAnd", tableName)
+	defer func() { endSpan(span, err) }()
+
 	var setItems []*QueryItem
 
 	if qp != nil && qp.ParamsStructPtr != nil {
 		setItems = ReflectQueryItems(reflect.ValueOf(qp.ParamsStructPtr).Elem(), qp.Required, qp.Conditions)
 	}
 
-	rows, err := so.Dao().SimpleSelectAnd(tableName, "*", qp.OrderBy, qp.Offset, qp.Cnt, setItems...)
+	rows, err := so.ReadDao().SimpleSelectAndCtx(ctx, tableName, "*", qp.OrderBy, qp.Offset, qp.Cnt, setItems...)
 
 	defer so.PutBackClient()
 
@@ -239,13 +626,114 @@ func (so *SimpleOrm) SimpleQueryAnd(tableName string, qp *QueryParams, entityTyp
 }
 
 func (so *SimpleOrm) SimpleTotalAnd(tableName string, qp *QueryParams) (int64, error) {
+	return so.SimpleTotalAndCtx(context.Background(), tableName, qp)
+}
+
+func (so *SimpleOrm) SimpleTotalAndCtx(ctx context.Context, tableName string, qp *QueryParams) (total int64, err error) {
+	ctx, span := so.startSpan(ctx, "SimpleOrm.SimpleTotalAnd", tableName)
+	defer func() { endSpan(span, err) }()
+
 	var items []*QueryItem
 	if qp != nil && qp.ParamsStructPtr != nil {
 		items = ReflectQueryItems(reflect.ValueOf(qp.ParamsStructPtr).Elem(), qp.Required, qp.Conditions)
 	}
 
-	total, err := so.Dao().SelectTotalAnd(tableName, items...)
+	total, err = so.ReadDao().SelectTotalAndCtx(ctx, tableName, items...)
 	defer so.PutBackClient()
 
 	return total, err
 }
+
+func (so *SimpleOrm) startSpan(ctx context.Context, name, tableName string) (context.Context, Span) {
+	if so.tracer == nil {
+		return ctx, noopSpan{}
+	}
+
+	ctx, span := so.tracer.StartSpan(ctx, name)
+	span.SetAttribute(AttrDBSystem, "mysql")
+	if tableName != "" {
+		span.SetAttribute(AttrDBSQLTable, tableName)
+	}
+
+	return ctx, span
+}
+
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.SetError(err)
+	}
+	span.End()
+}
+
+func (so *SimpleOrm) shardRule(tableName string) (*ShardRule, bool) {
+	if so.router == nil {
+		return nil, false
+	}
+
+	return so.router.Rule(tableName)
+}
+
+// shardById returns the shard of id in tableName, nil if tableName is not sharded.
+func (so *SimpleOrm) shardById(tableName string, id int64) (*Shard, error) {
+	rule, ok := so.shardRule(tableName)
+	if !ok {
+		return nil, nil
+	}
+	if rule.keyColumn() != DefaultShardKeyColumn {
+		return nil, errors.New("table " + tableName + " is sharded by " + rule.keyColumn() + ", not by id")
+	}
+
+	return so.router.Route(tableName, id)
+}
+
+// onShard runs fn with the orm switched to the pool of shard, in a transaction only the transaction's pool can be used.
+func (so *SimpleOrm) onShard(shard *Shard, fn func() error) error {
+	if so.inTransaction() {
+		if shard.Pool != so.pool {
+			return errors.New("shard " + shard.TableName + " is on another pool than the transaction")
+		}
+
+		return fn()
+	}
+
+	pool, cluster := so.pool, so.cluster
+	so.PutBackClient()
+	so.pool, so.cluster = shard.Pool, nil
+
+	defer func() {
+		so.PutBackClient()
+		so.pool, so.cluster = pool, cluster
+	}()
+
+	return fn()
+}
+
+// listByIdsOnShards queries every shard holding some of ids and merges the entities ordered by orderBy.
+func (so *SimpleOrm) listByIdsOnShards(ctx context.Context, tableName string, ids []int64, orderBy string, entityType reflect.Type, listPtr interface{}) error {
+	groups, err := so.router.GroupIds(tableName, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		err = so.onShard(group.Shard, func() error {
+			rows, err := so.ReadDao().SelectByIdsCtx(ctx, group.TableName, "*", orderBy, group.Ids...)
+			defer so.PutBackClient()
+
+			if err != nil {
+				return err
+			}
+
+			return ReflectQueryRowsToEntityList(rows, entityType, listPtr)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(groups) > 1 {
+		ReflectSortEntityList(listPtr, orderBy)
+	}
+
+	return nil
+}
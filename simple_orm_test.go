@@ -134,3 +134,140 @@ func TestOrmInsertGetListUpdateDelete(t *testing.T) {
 
 	fmt.Println(result)
 }
+
+func TestOrmTransaction(t *testing.T) {
+	config := &PoolConfig{NewClientFunc: newMysqlTestClient}
+	config.MaxConns = 100
+	config.MaxIdleTime = time.Second * 5
+
+	pool := NewPool(config)
+	logger, _ := golog.NewConsoleLogger(golog.LevelInfo)
+	orm := NewSimpleOrm([]byte("-"), pool, false).SetLogger(logger)
+
+	tableName := "demo"
+	err := orm.Transaction(func(tx *SimpleOrm) error {
+		ids, err := tx.Insert(tableName, tableName, "Id", &demoEntity{Name: "tx", Status: 1})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.UpdateById(tableName, ids[0], &demoEntity{Name: "tx-new"}, map[string]bool{"name": true})
+		return err
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func TestOrmCluster(t *testing.T) {
+	config := &PoolConfig{NewClientFunc: newMysqlTestClient}
+	config.MaxConns = 100
+	config.MaxIdleTime = time.Second * 5
+
+	cluster := NewClusterPool(NewPool(config)).
+		AddReplica("replica", NewPool(config), 1)
+	logger, _ := golog.NewConsoleLogger(golog.LevelInfo)
+	orm := NewClusterSimpleOrm([]byte("-"), cluster, false).SetLogger(logger)
+
+	tableName := "demo"
+	ids, err := orm.Insert(tableName, tableName, "Id", &demoEntity{Name: "cluster", Status: 1})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	item := &demoEntity{}
+	find, err := orm.SetForcePrimary(true).GetById(tableName, ids[0], item)
+	fmt.Println(find, err, item)
+}
+
+func TestOrmTracing(t *testing.T) {
+	recorder := NewSpanRecorder()
+
+	config := &PoolConfig{NewClientFunc: func() (*Client, error) {
+		client, err := newMysqlTestClient()
+		if err == nil {
+			client.Use(TracingMiddleware(recorder))
+		}
+		return client, err
+	}}
+	config.MaxConns = 10
+	config.MaxIdleTime = time.Second * 2
+
+	so := NewSimpleOrm([]byte("TestOrmTracing"), NewPool(config), false).SetTracer(recorder)
+
+	item := new(DemoItem)
+	so.GetById("people", 1, item)
+
+	for _, span := range recorder.Spans() {
+		parent := ""
+		if span.Parent != nil {
+			parent = span.Parent.Name
+		}
+		fmt.Println(span.Name, parent, span.Attributes, span.Err)
+	}
+}
+
+func TestOrmSave(t *testing.T) {
+	config := &PoolConfig{NewClientFunc: newMysqlTestClient}
+	config.MaxConns = 100
+	config.MaxIdleTime = time.Second * 5
+
+	pool := NewPool(config)
+	logger, _ := golog.NewConsoleLogger(golog.LevelInfo)
+	orm := NewSimpleOrm([]byte("-"), pool, false).SetLogger(logger)
+
+	tableName := "demo"
+	item := &demoEntity{Name: "save", Status: 1}
+	item.Id = 100
+
+	for i := 0; i < 3; i++ {
+		if i == 2 {
+			item.Status = 2
+		}
+
+		result, err := orm.Save(tableName, tableName, "Id", item)
+		fmt.Println(result, err)
+	}
+}
+
+func TestOrmGetByIdForUpdate(t *testing.T) {
+	config := &PoolConfig{NewClientFunc: newMysqlTestClient}
+	config.MaxConns = 100
+	config.MaxIdleTime = time.Second * 5
+
+	pool := NewPool(config)
+	logger, _ := golog.NewConsoleLogger(golog.LevelInfo)
+	orm := NewSimpleOrm([]byte("-"), pool, false).SetLogger(logger)
+
+	tableName := "demo"
+	err := orm.Transaction(func(tx *SimpleOrm) error {
+		item := new(demoEntity)
+		find, err := tx.GetByIdForUpdate(tableName, 1, item)
+		if err != nil || !find {
+			return err
+		}
+
+		_, err = tx.UpdateById(tableName, 1, &demoEntity{Status: item.Status + 1}, map[string]bool{"status": true})
+		return err
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	if _, err := orm.GetByIdForUpdate(tableName, 1, new(demoEntity)); err != ErrNotInTransaction {
+		t.Error("a locking read outside of a transaction should fail", err)
+	}
+
+	// a transaction begun on the client of the orm
+	client := orm.Dao().Client
+	err = client.Begin()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	find, err := orm.GetByIdForUpdate(tableName, 1, new(demoEntity))
+	fmt.Println(find, err)
+	fmt.Println(client.Commit())
+	orm.PutBackClient()
+}
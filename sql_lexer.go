@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"strings"
+)
+
+const (
+	tokSpace = iota
+	tokComment
+	tokIdent
+	tokQuotedIdent
+	tokString
+	tokNumber
+	tokPlaceholder
+	tokPunct
+	tokOperator
+)
+
+type sqlToken struct {
+	kind int
+	text string
+}
+
+// lexSQL splits query into tokens, the texts of the tokens concatenated give query back.
+// It knows enough of MySQL to find the placeholders: quoted strings and identifiers,
+// backslash escapes, -- and # line comments and /* */ block comments.
+func lexSQL(query string) []sqlToken {
+	var tokens []sqlToken
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		kind := tokOperator
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			kind = tokSpace
+			for i < len(query) && strings.IndexByte(" \t\n\r", query[i]) >= 0 {
+				i++
+			}
+		case c == '#' || (c == '-' && strings.HasPrefix(query[i:], "-- ")):
+			kind = tokComment
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			kind = tokComment
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || c == '"':
+			kind = tokString
+			i = skipQuoted(query, i, c)
+		case c == '`':
+			kind = tokQuotedIdent
+			i = skipQuoted(query, i, c)
+		case c == '?':
+			kind = tokPlaceholder
+			i++
+		case c >= '0' && c <= '9':
+			kind = tokNumber
+			for i < len(query) && (isIdentByte(query[i]) || query[i] == '.') {
+				i++
+			}
+		case isIdentByte(c):
+			kind = tokIdent
+			for i < len(query) && (isIdentByte(query[i]) || query[i] == '.') {
+				i++
+			}
+		case c == '(' || c == ')' || c == ',' || c == ';':
+			kind = tokPunct
+			i++
+		default:
+			for i++; i < len(query) && strings.IndexByte("=<>!", query[i]) >= 0 && strings.IndexByte("=<>!", c) >= 0; i++ {
+			}
+		}
+
+		tokens = append(tokens, sqlToken{kind: kind, text: query[start:i]})
+	}
+
+	return tokens
+}
+
+// skipQuoted returns the index following the quoted text starting at query[i],
+// a doubled quote or, except in identifiers, a backslash escapes the quote.
+func skipQuoted(query string, i int, quote byte) int {
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+
+	return len(query)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
@@ -0,0 +1,135 @@
+package mysql
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErUnknownStmtHandler is returned by the server for a statement it no longer knows, e.g. after a restart.
+const ErUnknownStmtHandler = 1243
+
+type StmtCacheStats struct {
+	Hits   int64
+	Misses int64
+	Size   int
+}
+
+type stmtCacheEntry struct {
+	query string
+	stmt  *sql.Stmt
+}
+
+// stmtCache keeps up to size statements prepared on the db, the least recently used one is closed when it is full.
+type stmtCache struct {
+	hits   int64
+	misses int64
+
+	lock    sync.Mutex
+	size    int
+	entries *list.List
+	index   map[string]*list.Element
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		entries: list.New(),
+		index:   make(map[string]*list.Element),
+	}
+}
+
+func (sc *stmtCache) get(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, error) {
+	if stmt := sc.lookup(query); stmt != nil {
+		atomic.AddInt64(&sc.hits, 1)
+		return stmt, nil
+	}
+
+	atomic.AddInt64(&sc.misses, 1)
+
+	// prepared without the lock, a slow prepare must not block the cached statements
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	if elem, ok := sc.index[query]; ok {
+		// prepared meanwhile by another goroutine
+		_ = stmt.Close()
+		sc.entries.MoveToFront(elem)
+		return elem.Value.(*stmtCacheEntry).stmt, nil
+	}
+
+	sc.index[query] = sc.entries.PushFront(&stmtCacheEntry{
+		query: query,
+		stmt:  stmt,
+	})
+
+	for sc.entries.Len() > sc.size {
+		sc.removeElement(sc.entries.Back())
+	}
+
+	return stmt, nil
+}
+
+func (sc *stmtCache) lookup(query string) *sql.Stmt {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	elem, ok := sc.index[query]
+	if !ok {
+		return nil
+	}
+
+	sc.entries.MoveToFront(elem)
+	return elem.Value.(*stmtCacheEntry).stmt
+}
+
+func (sc *stmtCache) evict(query string) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	if elem, ok := sc.index[query]; ok {
+		sc.removeElement(elem)
+	}
+}
+
+func (sc *stmtCache) close() {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	for sc.entries.Len() > 0 {
+		sc.removeElement(sc.entries.Back())
+	}
+}
+
+func (sc *stmtCache) stats() StmtCacheStats {
+	sc.lock.Lock()
+	size := sc.entries.Len()
+	sc.lock.Unlock()
+
+	return StmtCacheStats{
+		Hits:   atomic.LoadInt64(&sc.hits),
+		Misses: atomic.LoadInt64(&sc.misses),
+		Size:   size,
+	}
+}
+
+func (sc *stmtCache) removeElement(elem *list.Element) {
+	entry := sc.entries.Remove(elem).(*stmtCacheEntry)
+	delete(sc.index, entry.query)
+	_ = entry.stmt.Close()
+}
+
+func isUnknownStmtError(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == ErUnknownStmtHandler
+}
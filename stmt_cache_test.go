@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStmtCache(t *testing.T) {
+	client, _ := newMysqlTestClient()
+	client.EnableStmtCache(2)
+
+	for i := 0; i < 3; i++ {
+		raw := client.QueryRow("select * from people where id = ?", 10)
+		item := new(DemoItem)
+		err := raw.Scan(&item.Id, &item.Name, &item.Age)
+		if err != nil {
+			fmt.Println(err.Error())
+		} else {
+			fmt.Println(item)
+		}
+	}
+
+	client.Query("select * from people where name = ?", "a")
+	client.Query("select * from people where age = ?", 1)
+
+	stats := client.StmtCacheStats()
+	fmt.Println(stats.Hits, stats.Misses, stats.Size)
+}
+
+func TestStmtCacheDisabled(t *testing.T) {
+	client, _ := newMysqlTestClient()
+	client.EnableStmtCache(2).EnableStmtCache(0)
+
+	if client.stmtCache != nil || client.useStmtCache([]interface{}{1}) {
+		t.Error("a size <= 0 should disable the statement cache")
+	}
+}
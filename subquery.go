@@ -0,0 +1,26 @@
+package mysql
+
+// SelectFrom starts a select from the derived table sub, e.g. SelectFrom(sub, "t", "t.name, t.cnt").
+func (qb *QueryBuilder) SelectFrom(sub *QueryBuilder, alias, what string) *QueryBuilder {
+	qb.start("select " + what + " from ")
+	qb.buildValue(sub)
+	qb.query += " as " + alias
+	return qb
+}
+
+// JoinSub adds an inner join of the derived table sub on conds joined with and.
+func (qb *QueryBuilder) JoinSub(sub *QueryBuilder, alias string, on ...CondExpr) *QueryBuilder {
+	return qb.buildJoinSub("join", sub, alias, on)
+}
+
+func (qb *QueryBuilder) LeftJoinSub(sub *QueryBuilder, alias string, on ...CondExpr) *QueryBuilder {
+	return qb.buildJoinSub("left join", sub, alias, on)
+}
+
+func (qb *QueryBuilder) buildJoinSub(kind string, sub *QueryBuilder, alias string, on []CondExpr) *QueryBuilder {
+	qb.query += " " + kind + " "
+	qb.buildValue(sub)
+	qb.query += " as " + alias
+
+	return qb.buildCondClause(" on ", And(on...))
+}
@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubqueryCondition(t *testing.T) {
+	sub := new(QueryBuilder)
+	sub.Select("orders", "people_id").WhereAnd(NewCondition("amount", CondGreater, 100))
+
+	exists := new(QueryBuilder)
+	exists.Select("bans b", "1").Where(NewCondition("b.people_id", CondEqual, Col("p.id")), NewCondition("b.active", CondEqual, true))
+
+	qb := new(QueryBuilder)
+	qb.Select("people p", "*").
+		Where(
+			NewCondition("p.age", CondGreater, 18),
+			NewCondition("p.id", CondIn, sub),
+			NewCondition("", CondNotExists, exists),
+		).
+		Limit(0, 10)
+
+	expect := "select * from people p where p.age > ?  and p.id in (select people_id from orders where amount > ? ) and " +
+		"not exists (select 1 from bans b where b.people_id = p.id  and b.active = ? ) limit ?, ?"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{18, 100, true, int64(0), int64(10)}) {
+		t.Error(qb.Args())
+	}
+}
+
+func TestSubqueryTable(t *testing.T) {
+	totals := new(QueryBuilder)
+	totals.Select("orders", "people_id, sum(amount) as total").
+		WhereAnd(NewCondition("status", CondEqual, 1)).
+		GroupBy("people_id")
+
+	qb := new(QueryBuilder)
+	qb.SelectFrom(totals, "t", "t.people_id, t.total").
+		Where(NewCondition("t.total", CondGreater, 50))
+
+	expect := "select t.people_id, t.total from (select people_id, sum(amount) as total from orders where status = ?  group by people_id) as t where t.total > ? "
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{1, 50}) || len(totals.Args()) != 1 {
+		t.Error(qb.Args(), totals.Args())
+	}
+
+	qb.Select("people p", "p.name, t.total").
+		JoinSub(totals, "t", NewCondition("t.people_id", CondEqual, Col("p.id"))).
+		Where(NewCondition("p.age", CondLess, 30))
+
+	expect = "select p.name, t.total from people p join (select people_id, sum(amount) as total from orders where status = ?  group by people_id) as t on t.people_id = p.id  where p.age < ? "
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{1, 30}) {
+		t.Error(qb.Args())
+	}
+}
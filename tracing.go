@@ -0,0 +1,188 @@
+package mysql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	AttrDBSystem       = "db.system"
+	AttrDBName         = "db.name"
+	AttrDBStatement    = "db.statement"
+	AttrDBOperation    = "db.operation"
+	AttrDBSQLTable     = "db.sql.table"
+	AttrDBRowsAffected = "db.rows_affected"
+
+	SpanTransaction = "transaction"
+)
+
+// Tracer starts spans, it reads the parent span from ctx and returns a ctx carrying the new span.
+// An OpenTelemetry tracer is adapted by wrapping its trace.Span.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, value interface{})
+	SetError(err error)
+	End()
+}
+
+// TracingMiddleware makes a span for each statement of a client. An outermost transaction gets its own span,
+// parent of the spans of its statements when their ctx carries no span.
+func TracingMiddleware(tracer Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			c := op.Client
+			if SpanFromContext(ctx) == nil && c.txSpan != nil {
+				// only the span is taken from the transaction, the deadline and values stay the statement's own
+				ctx = ContextWithSpan(ctx, c.txSpan)
+			}
+
+			savepoint := op.IsSavepoint()
+			var txSpan Span
+			if op.Kind == OpBegin && !savepoint {
+				ctx, txSpan = tracer.StartSpan(ctx, SpanTransaction)
+				setDBAttributes(txSpan, c)
+			}
+
+			operation := statementOp(op)
+			table := statementTable(op.Query)
+			name := operation
+			if table != "" {
+				name += " " + table
+			}
+
+			spanCtx, span := tracer.StartSpan(ctx, name)
+			setDBAttributes(span, c)
+			span.SetAttribute(AttrDBStatement, op.Query)
+			span.SetAttribute(AttrDBOperation, operation)
+			if table != "" {
+				span.SetAttribute(AttrDBSQLTable, table)
+			}
+
+			err := next(spanCtx, op)
+
+			if op.Kind == OpExec && op.Err == nil && op.Result != nil {
+				if ra, err := op.Result.RowsAffected(); err == nil {
+					span.SetAttribute(AttrDBRowsAffected, ra)
+				}
+			}
+			if err != nil {
+				span.SetError(err)
+			}
+			span.End()
+
+			switch {
+			case txSpan != nil && err == nil:
+				c.txSpan = txSpan
+			case txSpan != nil:
+				txSpan.SetError(err)
+				txSpan.End()
+			case (op.Kind == OpCommit || op.Kind == OpRollback) && !savepoint && c.txSpan != nil:
+				if op.Kind == OpRollback || err != nil {
+					c.txSpan.SetAttribute(AttrDBOperation, OpRollback)
+				} else {
+					c.txSpan.SetAttribute(AttrDBOperation, OpCommit)
+				}
+				if err != nil {
+					c.txSpan.SetError(err)
+				}
+				c.txSpan.End()
+				c.txSpan = nil
+			}
+
+			return err
+		}
+	}
+}
+
+func setDBAttributes(span Span, c *Client) {
+	span.SetAttribute(AttrDBSystem, "mysql")
+	if c.config.Config != nil && c.config.DBName != "" {
+		span.SetAttribute(AttrDBName, c.config.DBName)
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+
+func (noopSpan) SetError(err error) {}
+
+func (noopSpan) End() {}
+
+// RecordedSpan is a span kept in memory by a SpanRecorder.
+type RecordedSpan struct {
+	Name       string
+	TraceId    []byte
+	Parent     *RecordedSpan
+	Attributes map[string]interface{}
+	Err        error
+	StartTime  time.Time
+	EndTime    time.Time
+
+	recorder *SpanRecorder
+}
+
+func (s *RecordedSpan) SetAttribute(key string, value interface{}) {
+	s.recorder.lock.Lock()
+	s.Attributes[key] = value
+	s.recorder.lock.Unlock()
+}
+
+func (s *RecordedSpan) SetError(err error) {
+	s.recorder.lock.Lock()
+	s.Err = err
+	s.recorder.lock.Unlock()
+}
+
+func (s *RecordedSpan) End() {
+	s.recorder.lock.Lock()
+	s.EndTime = time.Now()
+	s.recorder.ended = append(s.recorder.ended, s)
+	s.recorder.lock.Unlock()
+}
+
+// SpanRecorder is an in-memory Tracer for tests, a root span takes its TraceId from the ctx, see ContextWithTraceId.
+type SpanRecorder struct {
+	lock  sync.Mutex
+	ended []*RecordedSpan
+}
+
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+func (r *SpanRecorder) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	span := &RecordedSpan{
+		Name:       name,
+		Attributes: make(map[string]interface{}),
+		StartTime:  time.Now(),
+		recorder:   r,
+	}
+
+	if parent, ok := SpanFromContext(ctx).(*RecordedSpan); ok {
+		span.Parent = parent
+		span.TraceId = parent.TraceId
+	} else {
+		span.TraceId = TraceIdFromContext(ctx)
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Spans returns the ended spans, in the order they ended.
+func (r *SpanRecorder) Spans() []*RecordedSpan {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]*RecordedSpan(nil), r.ended...)
+}
+
+func (r *SpanRecorder) Reset() {
+	r.lock.Lock()
+	r.ended = nil
+	r.lock.Unlock()
+}
@@ -0,0 +1,107 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (r fakeResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := NewSpanRecorder()
+	client := &Client{config: NewConfig("root", "passwd", "127.0.0.1", "3306", "demo")}
+	handler := chainMiddlewares(func(ctx context.Context, op *Operation) error {
+		op.Start = time.Now()
+		if op.Kind == OpExec {
+			op.Result = fakeResult(2)
+		}
+		return nil
+	}, []Middleware{TracingMiddleware(recorder)})
+
+	run := func(ctx context.Context, kind, query string) {
+		handler(ctx, &Operation{Kind: kind, Query: query, Client: client})
+	}
+
+	ctx, parent := recorder.StartSpan(ContextWithTraceId(context.Background(), []byte("trace-1")), "handler")
+	run(ctx, OpQuery, "select * from people where id = ?")
+	run(ctx, OpBegin, "BEGIN")
+	run(context.Background(), OpExec, "update people set age = ? where id = ?")
+	run(context.Background(), OpCommit, "COMMIT")
+	parent.End()
+
+	spans := recorder.Spans()
+	if len(spans) != 6 {
+		t.Fatal(len(spans))
+	}
+
+	names := []string{"select people", "begin", "update people", "commit", SpanTransaction, "handler"}
+	for i, span := range spans {
+		if span.Name != names[i] {
+			t.Error(i, span.Name)
+		}
+		if string(span.TraceId) != "trace-1" {
+			t.Error(span.Name, string(span.TraceId))
+		}
+	}
+
+	tx := spans[4]
+	if spans[0].Parent != spans[5] || tx.Parent != spans[5] {
+		t.Error("statements should be children of the ctx span")
+	}
+	if spans[1].Parent != tx || spans[2].Parent != tx || spans[3].Parent != tx {
+		t.Error("transaction statements should be children of the transaction span")
+	}
+
+	update := spans[2].Attributes
+	if update[AttrDBSystem] != "mysql" || update[AttrDBName] != "demo" || update[AttrDBOperation] != "update" ||
+		update[AttrDBSQLTable] != "people" || update[AttrDBRowsAffected] != int64(2) ||
+		update[AttrDBStatement] != "update people set age = ? where id = ?" {
+		t.Error(update)
+	}
+	if client.txSpan != nil {
+		t.Error("the transaction span should be cleared")
+	}
+}
+
+func TestTracingMiddlewareTxContext(t *testing.T) {
+	recorder := NewSpanRecorder()
+	client := &Client{config: NewConfig("root", "passwd", "127.0.0.1", "3306", "demo")}
+
+	var execErr error
+	var execDeadline bool
+	handler := chainMiddlewares(func(ctx context.Context, op *Operation) error {
+		op.Start = time.Now()
+		if op.Kind == OpExec {
+			execErr = ctx.Err()
+			_, execDeadline = ctx.Deadline()
+		}
+		return nil
+	}, []Middleware{TracingMiddleware(recorder)})
+
+	beginCtx, cancel := context.WithCancel(context.Background())
+	handler(beginCtx, &Operation{Kind: OpBegin, Query: "BEGIN", Client: client})
+	cancel()
+
+	ctx, cancelExec := context.WithTimeout(ContextWithTraceId(context.Background(), []byte("trace-2")), time.Minute)
+	defer cancelExec()
+	handler(ctx, &Operation{Kind: OpExec, Query: "update people set age = ? where id = ?", Client: client})
+	handler(context.Background(), &Operation{Kind: OpCommit, Query: "COMMIT", Client: client})
+
+	if execErr != nil || !execDeadline {
+		t.Error("the statement should keep its own context", execErr, execDeadline)
+	}
+
+	spans := recorder.Spans()
+	if len(spans) != 4 || spans[1].Name != "update people" || spans[1].Parent != spans[3] {
+		t.Error("the statement should be a child of the transaction span")
+	}
+}
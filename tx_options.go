@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TxOptions struct {
+	// Isolation is one of sql.LevelDefault, sql.LevelReadUncommitted, sql.LevelReadCommitted,
+	// sql.LevelRepeatableRead and sql.LevelSerializable.
+	Isolation sql.IsolationLevel
+	// ReadOnly starts the transaction with START TRANSACTION READ ONLY.
+	ReadOnly bool
+	// LockWaitTimeout sets innodb_lock_wait_timeout for the duration of the transaction,
+	// it is rounded up to whole seconds and the session value is restored before the transaction ends.
+	LockWaitTimeout time.Duration
+}
+
+func (o *TxOptions) IsZero() bool {
+	return o == nil || (o.Isolation == sql.LevelDefault && !o.ReadOnly && o.LockWaitTimeout <= 0)
+}
+
+func (o *TxOptions) String() string {
+	if o.IsZero() {
+		return ""
+	}
+
+	var parts []string
+	if o.Isolation != sql.LevelDefault {
+		parts = append(parts, "ISOLATION LEVEL "+strings.ToUpper(o.Isolation.String()))
+	}
+	if o.ReadOnly {
+		parts = append(parts, "READ ONLY")
+	}
+	if o.LockWaitTimeout > 0 {
+		parts = append(parts, "innodb_lock_wait_timeout = "+strconv.FormatInt(o.lockWaitTimeoutSeconds(), 10))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (o *TxOptions) validate() error {
+	switch o.Isolation {
+	case sql.LevelDefault, sql.LevelReadUncommitted, sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable:
+		return nil
+	}
+
+	return errors.New("isolation level " + o.Isolation.String() + " is not supported by mysql")
+}
+
+func (o *TxOptions) txOptions() *sql.TxOptions {
+	return &sql.TxOptions{
+		Isolation: o.Isolation,
+		ReadOnly:  o.ReadOnly,
+	}
+}
+
+func (o *TxOptions) lockWaitTimeoutSeconds() int64 {
+	return int64((o.LockWaitTimeout + time.Second - 1) / time.Second)
+}
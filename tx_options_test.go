@@ -0,0 +1,29 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestTxOptionsString(t *testing.T) {
+	opts := &TxOptions{
+		Isolation:       sql.LevelSerializable,
+		ReadOnly:        true,
+		LockWaitTimeout: time.Millisecond * 1500,
+	}
+
+	expect := "ISOLATION LEVEL SERIALIZABLE, READ ONLY, innodb_lock_wait_timeout = 2"
+	if s := opts.String(); s != expect {
+		t.Error(s)
+	}
+
+	var nilOpts *TxOptions
+	if !nilOpts.IsZero() || nilOpts.String() != "" {
+		t.Error("nil options should be zero")
+	}
+
+	if err := (&TxOptions{Isolation: sql.LevelSnapshot}).validate(); err == nil {
+		t.Error("snapshot isolation should be rejected")
+	}
+}
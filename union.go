@@ -0,0 +1,42 @@
+package mysql
+
+// With adds the common table expression name, e.g. "t" or "t (id, depth)", defined by sub,
+// to the statement started by the next verb: qb.With("t", sub).Select("t", "*").
+func (qb *QueryBuilder) With(name string, sub *QueryBuilder) *QueryBuilder {
+	qb.ctes = append(qb.ctes, name+" as ("+sub.query+")")
+	qb.cteArgs = append(qb.cteArgs, sub.args...)
+	return qb
+}
+
+// WithRecursive adds a recursive common table expression, sub usually is the union all of
+// its anchor and recursive parts.
+func (qb *QueryBuilder) WithRecursive(name string, sub *QueryBuilder) *QueryBuilder {
+	qb.recursive = true
+	return qb.With(name, sub)
+}
+
+// Union combines the query with the one of other, a part ending with an order by or a limit is parenthesized.
+// The OrderBy and Limit called next apply to the combined result.
+func (qb *QueryBuilder) Union(other *QueryBuilder) *QueryBuilder {
+	return qb.buildUnion("union", other)
+}
+
+func (qb *QueryBuilder) UnionAll(other *QueryBuilder) *QueryBuilder {
+	return qb.buildUnion("union all", other)
+}
+
+func (qb *QueryBuilder) buildUnion(kind string, other *QueryBuilder) *QueryBuilder {
+	if qb.ordered {
+		qb.query = "(" + qb.query + ")"
+	}
+
+	if other.ordered {
+		qb.query += " " + kind + " (" + other.query + ")"
+	} else {
+		qb.query += " " + kind + " " + other.query
+	}
+	qb.args = append(qb.args, other.args...)
+	qb.ordered = false
+
+	return qb
+}
@@ -0,0 +1,70 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnion(t *testing.T) {
+	young := new(QueryBuilder)
+	young.Select(TABLE_NAME, "id, name").WhereAnd(NewCondition("age", CondLess, 10))
+
+	top := new(QueryBuilder)
+	top.Select(TABLE_NAME, "id, name").OrderBy("age desc").Limit(0, 3)
+
+	qb := new(QueryBuilder)
+	qb.Select("archived_people", "id, name").WhereAnd(NewCondition("name", CondEqual, "a")).
+		Union(young).
+		UnionAll(top).
+		OrderBy("id").
+		Limit(0, 10)
+
+	expect := "select id, name from archived_people where name = ?  union select id, name from people where age < ?  " +
+		"union all (select id, name from people order by age desc limit ?, ?) order by id limit ?, ?"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{"a", 10, int64(0), int64(3), int64(0), int64(10)}) {
+		t.Error(qb.Args())
+	}
+
+	qb.Union(young)
+	if qb.Query()[0] != '(' {
+		t.Error(qb.Query())
+	}
+}
+
+func TestWith(t *testing.T) {
+	anchor := new(QueryBuilder)
+	anchor.Select("categories", "id, parent_id, 1").WhereAnd(NewCondition("id", CondEqual, 7))
+
+	step := new(QueryBuilder)
+	step.Select("categories c", "c.id, c.parent_id, t.depth + 1").
+		Join("tree t", NewCondition("c.parent_id", CondEqual, Col("t.id"))).
+		WhereAnd(NewCondition("t.depth", CondLess, 5))
+
+	adults := new(QueryBuilder)
+	adults.Select(TABLE_NAME, "id").WhereAnd(NewCondition("age", CondGreaterEqual, 18))
+
+	qb := new(QueryBuilder)
+	qb.WithRecursive("tree (id, parent_id, depth)", anchor.UnionAll(step)).
+		With("adults", adults).
+		Select("tree", "*").
+		Where(NewCondition("id", CondNotIn, []int{1, 2})).
+		OrderBy("depth")
+
+	expect := "with recursive tree (id, parent_id, depth) as (select id, parent_id, 1 from categories where id = ?  union all " +
+		"select c.id, c.parent_id, t.depth + 1 from categories c join tree t on c.parent_id = t.id  where t.depth < ? ), " +
+		"adults as (select id from people where age >= ? ) select * from tree where id not in (?, ?) order by depth"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{7, 5, 18, 1, 2}) {
+		t.Error(qb.Args())
+	}
+
+	qb.Select(TABLE_NAME, "*")
+	if qb.Query() != "select * from people" || len(qb.Args()) != 0 {
+		t.Error("the ctes should apply to one statement only", qb.Query())
+	}
+}
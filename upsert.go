@@ -0,0 +1,103 @@
+package mysql
+
+import (
+	"context"
+)
+
+// UpsertResult tells what a single row upsert did, decoded from its rows affected by UpsertResultOf.
+type UpsertResult int
+
+const (
+	UpsertUnchanged UpsertResult = iota
+	UpsertInserted
+	UpsertUpdated
+)
+
+func (r UpsertResult) String() string {
+	switch r {
+	case UpsertInserted:
+		return "inserted"
+	case UpsertUpdated:
+		return "updated"
+	}
+
+	return "unchanged"
+}
+
+// UpsertResultOf decodes the rows affected by a single row insert ... on duplicate key update: 1 when the row
+// was inserted, 2 when an existing row was updated and 0 when it was left as is. The clientFoundRows param
+// of the dsn makes the server report 1 for an unchanged row, which is then taken for an insert.
+func UpsertResultOf(rowsAffected int64) UpsertResult {
+	switch rowsAffected {
+	case 1:
+		return UpsertInserted
+	case 2:
+		return UpsertUpdated
+	}
+
+	return UpsertUnchanged
+}
+
+// NewIncrPair returns the pair setting name to name + delta, e.g. in Set or OnDuplicateKeyUpdate.
+func NewIncrPair(name string, delta interface{}) *QueryItem {
+	return NewPair(name, Incr(name, delta))
+}
+
+// ValuesCol references the value an insert ... on duplicate key update tried to insert in columnName.
+// With MySQL 8.0.19 and later, RowAlias and a Col such as Col("new.age") do the same.
+func ValuesCol(columnName string) Col {
+	return Col("values(" + columnName + ")")
+}
+
+func (qb *QueryBuilder) InsertIgnore(tableName string, columnNames ...string) *QueryBuilder {
+	return qb.buildInsert("insert ignore into ", tableName, columnNames)
+}
+
+func (qb *QueryBuilder) Replace(tableName string, columnNames ...string) *QueryBuilder {
+	return qb.buildInsert("replace into ", tableName, columnNames)
+}
+
+// RowAlias names the inserted row, to be called after Values, see ValuesCol.
+func (qb *QueryBuilder) RowAlias(alias string) *QueryBuilder {
+	qb.query += " as " + alias
+	return qb
+}
+
+// OnDuplicateKeyUpdate adds the assignments applied when the insert hits a primary or unique key,
+// a value may be a Col, e.g. ValuesCol("age"), or come from NewIncrPair.
+func (qb *QueryBuilder) OnDuplicateKeyUpdate(items ...*QueryItem) *QueryBuilder {
+	if len(items) == 0 {
+		return qb
+	}
+
+	qb.query += " on duplicate key update "
+	for i, item := range items {
+		if i > 0 {
+			qb.query += ", "
+		}
+		qb.query += item.Name + " = "
+		qb.buildValue(item.Value)
+	}
+
+	return qb
+}
+
+// Upsert inserts the rows, the ones hitting a primary or unique key update their updateColNames instead.
+// RowsAffected of a single row is decoded by UpsertResultOf.
+func (d *Dao) Upsert(tableName string, colNames []string, colValues [][]interface{}, updateColNames ...string) *ExecResult {
+	return d.UpsertCtx(context.Background(), tableName, colNames, colValues, updateColNames...)
+}
+
+func (d *Dao) UpsertCtx(ctx context.Context, tableName string, colNames []string, colValues [][]interface{}, updateColNames ...string) *ExecResult {
+	items := make([]*QueryItem, len(updateColNames))
+	for i, colName := range updateColNames {
+		items[i] = NewPair(colName, ValuesCol(colName))
+	}
+
+	qb := new(QueryBuilder)
+	qb.Insert(tableName, colNames...).
+		Values(colValues...).
+		OnDuplicateKeyUpdate(items...)
+
+	return GetExecResult(d.ExecContext(ctx, qb.Query(), qb.Args()...))
+}
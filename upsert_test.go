@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOnDuplicateKeyUpdate(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.Insert(TABLE_NAME, "id", "name", "age").
+		Values([]interface{}{1, "a", 5}, []interface{}{2, "b", 6}).
+		OnDuplicateKeyUpdate(NewPair("name", ValuesCol("name")), NewIncrPair("age", 1), NewPair("status", 0))
+
+	expect := "insert into people (id, name, age) values (?, ?, ?), (?, ?, ?) on duplicate key update " +
+		"name = values(name), age = age + ?, status = ?"
+	if qb.Query() != expect {
+		t.Error(qb.Query())
+	}
+	if !reflect.DeepEqual(qb.Args(), []interface{}{1, "a", 5, 2, "b", 6, 1, 0}) {
+		t.Error(qb.Args())
+	}
+
+	qb.Insert(TABLE_NAME, "id", "name").
+		Values([]interface{}{1, "a"}).
+		RowAlias("new").
+		OnDuplicateKeyUpdate(NewPair("name", Col("new.name")))
+
+	if qb.Query() != "insert into people (id, name) values (?, ?) as new on duplicate key update name = new.name" {
+		t.Error(qb.Query())
+	}
+}
+
+func TestInsertIgnoreReplace(t *testing.T) {
+	qb := new(QueryBuilder)
+	qb.InsertIgnore(TABLE_NAME, "id", "name").Values([]interface{}{1, "a"})
+	if qb.Query() != "insert ignore into people (id, name) values (?, ?)" {
+		t.Error(qb.Query())
+	}
+
+	qb.Replace(TABLE_NAME, "id").Values([]interface{}{1})
+	if qb.Query() != "replace into people (id) values (?)" {
+		t.Error(qb.Query())
+	}
+}
+
+func TestUpsertResultOf(t *testing.T) {
+	for rowsAffected, expect := range map[int64]UpsertResult{0: UpsertUnchanged, 1: UpsertInserted, 2: UpsertUpdated} {
+		if result := UpsertResultOf(rowsAffected); result != expect {
+			t.Error(rowsAffected, result)
+		}
+	}
+}